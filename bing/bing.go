@@ -0,0 +1,315 @@
+package bing
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/awolverp/dorkali"
+	"github.com/awolverp/dorkali/html"
+)
+
+var (
+	_ dorkali.Engine = (*BingEngine)(nil)
+	_ dorkali.Result = (*BingResult)(nil)
+)
+
+const (
+	Version = "v1.0.0"
+
+	URL = "https://www.bing.com/search"
+
+	// maximum number of results per page which bing accepts
+	maxCount = 50
+)
+
+func init() {
+	dorkali.RegisterEngine("bing", NewBingEngine)
+}
+
+type BingEngine struct {
+	Opt options
+
+	session *dorkali.Session
+}
+
+func NewBingEngine() dorkali.Engine {
+	return &BingEngine{
+		session: dorkali.NewSession(),
+	}
+}
+
+func (engine *BingEngine) Start() error {
+	return nil
+}
+
+func (engine *BingEngine) Version() string {
+	return Version
+}
+
+func (engine *BingEngine) Description() string {
+	return "Searches in bing search engine"
+}
+
+func (engine *BingEngine) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&engine.Opt.UserAgent, "U", engine.Opt.UserAgent, "") // user agent
+}
+
+func (engine *BingEngine) Usage() {
+	fmt.Print(flagUsageText)
+}
+
+func (engine *BingEngine) SetSession(s *dorkali.Session) {
+	engine.session = s
+}
+
+func (engine *BingEngine) Session() *dorkali.Session {
+	return engine.session
+}
+
+func (engine *BingEngine) Search(sr *dorkali.SearchRequest) (*http.Response, error) {
+	return engine.SearchContext(context.Background(), sr)
+}
+
+func (engine *BingEngine) SearchContext(ctx context.Context, sr *dorkali.SearchRequest) (*http.Response, error) {
+	if err := sr.Validate(); err != nil {
+		return nil, err
+	}
+
+	if sr.PerPage() > maxCount {
+		return nil, fmt.Errorf("bing: count must be at most %d", maxCount)
+	}
+
+	if err := engine.Start(); err != nil {
+		return nil, err
+	}
+
+	uri := generate_url(sr, time.Now())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if engine.Opt.UserAgent != "" {
+		req.Header.Add("User-Agent", engine.Opt.UserAgent)
+	}
+
+	if sr.Language != "" {
+		req.Header.Add("Accept-Language", sr.Language)
+	}
+
+	for k, values := range sr.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+
+	for _, c := range sr.Cookies {
+		req.AddCookie(c)
+	}
+
+	resp, err := engine.session.Do(req, sr.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dorkali.CheckStatus("bing", resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (engine *BingEngine) ParseResponse(response *http.Response) ([]dorkali.Result, error) {
+	return engine.ParseResponseContext(context.Background(), response)
+}
+
+func (engine *BingEngine) ParseResponseContext(ctx context.Context, response *http.Response) ([]dorkali.Result, error) {
+	b, err := dorkali.ReadBody(ctx, response)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := html.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(doc)
+}
+
+func (engine *BingEngine) ParseHTML(h string) ([]dorkali.Result, error) {
+	doc, err := html.Parse(strings.NewReader(h))
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(doc)
+}
+
+// parse returns results of doc, and dorkali.ErrNoMoreResults if there's no next page
+func parse(doc *html.HTMLParser) ([]dorkali.Result, error) {
+	var res []dorkali.Result
+
+	doc.FindAllFunc(&html.Match{Name: "li", Attributes: map[string]string{"class": "b_algo"}}, func(e *html.Element) {
+		res = append(res, &BingResult{e})
+	})
+
+	if len(res) == 0 || doc.Find(&html.Match{Name: "a", Attributes: map[string]string{"class": "sb_pagN"}}) == nil {
+		return res, dorkali.ErrNoMoreResults
+	}
+
+	return res, nil
+}
+
+func generate_url(sr *dorkali.SearchRequest, now time.Time) string {
+	u, _ := url.Parse(URL)
+	q := u.Query()
+
+	if sr.Language != "" {
+		q.Set("setlang", sr.Language)
+	}
+
+	if sr.Region != "" {
+		q.Set("cc", strings.ToUpper(sr.Region))
+
+		if sr.Language != "" {
+			q.Set("mkt", strings.ToLower(sr.Language)+"-"+strings.ToUpper(sr.Region))
+		}
+	}
+
+	if sr.SafeSearch {
+		q.Set("adlt", "strict")
+	} else {
+		q.Set("adlt", "off")
+	}
+
+	if f := time_filter(sr.TimeRange, now); f != "" {
+		q.Set("filters", f)
+	}
+
+	if start := sr.Offset(); start != 0 {
+		q.Set("first", strconv.Itoa(start+1))
+	}
+
+	q.Set("count", strconv.Itoa(sr.PerPage()))
+
+	query := strings.TrimSpace(sr.Query)
+
+	filetype := sr.Filetype
+	if filetype == "" {
+		filetype = sr.Ext
+	}
+
+	for _, op := range []string{
+		dorkali.Operator("site", sr.Site),
+		dorkali.Operator("instreamset:(url)", sr.Inurl),
+		dorkali.Operator("intitle", sr.Intitle),
+		dorkali.Operator("inbody", sr.Intext),
+		dorkali.Operator("filetype", filetype),
+	} {
+		if op != "" {
+			query += " " + op
+		}
+	}
+
+	q.Set("q", strings.TrimSpace(query))
+
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// time_filter returns value of bing "filters" parameter
+//
+// bing has no past hour filter, so it uses past day instead.
+func time_filter(t dorkali.TimeRange, now time.Time) string {
+	switch t {
+	case dorkali.PastHour, dorkali.PastDay:
+		return `ex1:"ez1"`
+	case dorkali.PastWeek:
+		return `ex1:"ez2"`
+	case dorkali.PastMonth:
+		return `ex1:"ez3"`
+	case dorkali.PastYear:
+		// custom range; days since unix epoch
+		to := now.Unix() / 86400
+		return fmt.Sprintf(`ex1:"ez5_%d_%d"`, to-365, to)
+	}
+
+	return ""
+}
+
+type BingResult struct {
+	Doc *html.Element
+}
+
+func (r *BingResult) Title() string {
+	title := r.Doc.Find(&html.Match{Name: "a", Parent: &html.Match{Name: "h2"}})
+	if title == nil {
+		return ""
+	}
+
+	return title.Text()
+}
+
+func (r *BingResult) Description() string {
+	d := r.Doc.Find(&html.Match{Name: "p"})
+	if d == nil {
+		return ""
+	}
+
+	return d.Text()
+}
+
+func (r *BingResult) Url() string {
+	h := r.Doc.Find(&html.Match{Name: "a", Parent: &html.Match{Name: "h2"}})
+	if h == nil {
+		return ""
+	}
+
+	return filter_url(h.Attr("href"))
+}
+
+func (r *BingResult) String() string {
+	return fmt.Sprintf("> %s\n%s\n%s\n", r.Url(), r.Title(), r.Description())
+}
+
+// filter_url unwraps bing click tracking urls
+//
+//	https://www.bing.com/ck/a?!&&p=...&u=a1aHR0cHM6Ly9leGFtcGxlLmNvbS8&ntb=1
+//		-> https://example.com/
+func filter_url(u string) string {
+	if u == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+
+	if !strings.HasSuffix(parsed.Host, "bing.com") || !strings.HasPrefix(parsed.Path, "/ck/") {
+		return u
+	}
+
+	encoded := parsed.Query().Get("u")
+	if !strings.HasPrefix(encoded, "a1") {
+		return u
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded[2:], "="))
+	if err != nil {
+		return u
+	}
+
+	return string(decoded)
+}
@@ -0,0 +1,184 @@
+package bing
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awolverp/dorkali"
+	"github.com/awolverp/dorkali/html"
+)
+
+func parseFixture(t *testing.T, name string) ([]dorkali.Result, error) {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := html.Parse(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parse(doc)
+}
+
+func TestParse(t *testing.T) {
+	results, err := parseFixture(t, "results.html")
+	if err != nil {
+		t.Fatalf("parse() error = %v, want nil", err)
+	}
+
+	want := []struct {
+		title, url, description string
+	}{
+		{
+			"Example Domain",
+			"https://example.com/",
+			"This domain is for use in illustrative examples in documents.",
+		},
+		{
+			"Documentation - The Go Programming Language",
+			"https://golang.org/doc/?a=1&b=2",
+			"The Go programming language is an open source project.",
+		},
+		{
+			"Go (programming language) - Wikipedia",
+			"https://www.wikipedia.org/wiki/Go_(programming_language)",
+			"Go is a statically typed, compiled high-level programming language.",
+		},
+	}
+
+	if len(results) != len(want) {
+		t.Fatalf("parse() returns %d results, want %d", len(results), len(want))
+	}
+
+	for i, w := range want {
+		r := results[i]
+
+		if got := r.Title(); got != w.title {
+			t.Errorf("results[%d].Title() = %q, want %q", i, got, w.title)
+		}
+
+		if got := r.Url(); got != w.url {
+			t.Errorf("results[%d].Url() = %q, want %q", i, got, w.url)
+		}
+
+		if got := r.Description(); got != w.description {
+			t.Errorf("results[%d].Description() = %q, want %q", i, got, w.description)
+		}
+	}
+}
+
+func TestParseLastPage(t *testing.T) {
+	for _, tt := range []struct {
+		file  string
+		count int
+	}{
+		{"last_page.html", 1},
+		{"no_results.html", 0},
+	} {
+		results, err := parseFixture(t, tt.file)
+		if err != dorkali.ErrNoMoreResults {
+			t.Errorf("%s: parse() error = %v, want ErrNoMoreResults", tt.file, err)
+		}
+
+		if len(results) != tt.count {
+			t.Errorf("%s: parse() returns %d results, want %d", tt.file, len(results), tt.count)
+		}
+	}
+}
+
+func TestFilterURL(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"https://example.com/page", "https://example.com/page"},
+		{"https://www.bing.com/ck/a?!&&p=1f2e&u=a1aHR0cHM6Ly9leGFtcGxlLmNvbS8&ntb=1", "https://example.com/"},
+		{"https://www.bing.com/ck/a?!&&p=1f2e&u=a1aHR0cHM6Ly9leGFtcGxlLmNvbS8=&ntb=1", "https://example.com/"},
+		{"https://www.bing.com/ck/a?u=a1aHR0cHM6Ly9nb2xhbmcub3JnL2RvYy8_YT0xJmI9Mg&ntb=1", "https://golang.org/doc/?a=1&b=2"},
+		// not a click tracking url
+		{"https://www.bing.com/search?q=x&u=a1aHR0cHM6Ly9leGFtcGxlLmNvbS8", "https://www.bing.com/search?q=x&u=a1aHR0cHM6Ly9leGFtcGxlLmNvbS8"},
+		// unknown encoding and invalid base64 are kept
+		{"https://www.bing.com/ck/a?u=b2aHR0cHM6Ly9leGFtcGxlLmNvbS8", "https://www.bing.com/ck/a?u=b2aHR0cHM6Ly9leGFtcGxlLmNvbS8"},
+		{"https://www.bing.com/ck/a?u=a1!!!", "https://www.bing.com/ck/a?u=a1!!!"},
+	} {
+		if got := filter_url(tt.in); got != tt.want {
+			t.Errorf("filter_url(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateURL(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name string
+		sr   *dorkali.SearchRequest
+		want map[string]string
+	}{
+		{
+			name: "defaults",
+			sr:   &dorkali.SearchRequest{Query: "golang"},
+			want: map[string]string{"q": "golang", "count": "10", "adlt": "off", "first": "", "setlang": "", "cc": "", "mkt": "", "filters": ""},
+		},
+		{
+			name: "language and region",
+			sr:   &dorkali.SearchRequest{Query: "golang", Language: "de", Region: "at"},
+			want: map[string]string{"setlang": "de", "cc": "AT", "mkt": "de-AT"},
+		},
+		{
+			name: "region without language",
+			sr:   &dorkali.SearchRequest{Query: "golang", Region: "us"},
+			want: map[string]string{"cc": "US", "mkt": "", "setlang": ""},
+		},
+		{
+			name: "page and count",
+			sr:   &dorkali.SearchRequest{Query: "golang", Page: 2, Count: 20},
+			want: map[string]string{"first": "41", "count": "20"},
+		},
+		{
+			name: "safe search",
+			sr:   &dorkali.SearchRequest{Query: "golang", SafeSearch: true},
+			want: map[string]string{"adlt": "strict"},
+		},
+		{
+			name: "time range",
+			sr:   &dorkali.SearchRequest{Query: "golang", TimeRange: dorkali.PastYear},
+			want: map[string]string{"filters": `ex1:"ez5_19359_19724"`},
+		},
+		{
+			name: "operators",
+			sr: &dorkali.SearchRequest{
+				Query: " golang ", Site: "go.dev", Inurl: "doc", Intitle: "effective go",
+				Intext: "interfaces", Ext: "pdf",
+			},
+			want: map[string]string{
+				"q": `golang site:go.dev instreamset:(url):doc intitle:"effective go" inbody:interfaces filetype:pdf`,
+			},
+		},
+	} {
+		u, err := url.Parse(generate_url(tt.sr, now))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if got := u.Scheme + "://" + u.Host + u.Path; got != URL {
+			t.Errorf("%s: url = %q, want %q", tt.name, got, URL)
+		}
+
+		q := u.Query()
+
+		for k, want := range tt.want {
+			if got := q.Get(k); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, k, got, want)
+			}
+		}
+	}
+}
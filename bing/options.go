@@ -0,0 +1,12 @@
+package bing
+
+const flagUsageText = "*Bing Options:\n" +
+	"\t-U User-Agent       Pass custom User-Agent header. (default from -profile)\n\n" +
+	"*Notes:\n" +
+	"\t-count accepts at most 50. -lang and -region together set the market. (e.g. en-US)\n" +
+	"\t-inurl is sent as instreamset:(url):TEXT, -intext as inbody:TEXT and -ext as filetype:TEXT.\n"
+
+type options struct {
+	// (Request Options) Request User-Agent
+	UserAgent string
+}
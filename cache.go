@@ -0,0 +1,176 @@
+package dorkali
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCacheMiss is returned in offline mode when a request is not in cache
+var ErrCacheMiss = errors.New("dorkali: not in cache")
+
+// DefaultCacheTTL is the default time which cached responses are used
+const DefaultCacheTTL = time.Hour * 24
+
+// CacheKeyer is implemented by engines which responses depend on their
+// options (e.g. google tld); CacheKey is added to cache key of requests
+type CacheKeyer interface {
+	CacheKey() string
+}
+
+// Cache keeps html of responses on disk, keyed by engine and normalized
+// search request.
+type Cache struct {
+	// Dir of cached files
+	Dir string
+
+	// TTL of cached responses; zero means they never expire
+	TTL time.Duration
+
+	// Refresh doesn't use cached responses, but caches new responses
+	Refresh bool
+
+	// Offline uses only cached responses, and returns ErrCacheMiss for others
+	Offline bool
+}
+
+// CacheEntry is a cached response
+type CacheEntry struct {
+	// Key of entry
+	Key string `json:"key"`
+
+	// URL of request
+	URL string `json:"url"`
+
+	// StatusCode and Header of response
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+
+	// Time which response is received
+	Time time.Time `json:"time"`
+
+	// Body is decoded html of response; it's kept in a separate file
+	Body []byte `json:"-"`
+}
+
+// NewCache returns cache of dir with ttl
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+// Key returns cache key of req of engine; extra is CacheKey() of engine, if implemented
+func (c *Cache) Key(engine string, req *SearchRequest, extra string) string {
+	fields := []string{
+		engine,
+		extra,
+		strings.Join(strings.Fields(req.Query), " "),
+		strconv.Itoa(req.Page),
+		strconv.Itoa(req.PerPage()),
+		strings.ToLower(req.Language),
+		strings.ToLower(req.Region),
+		strconv.FormatBool(req.SafeSearch),
+		string(req.TimeRange),
+		req.Site,
+		req.Inurl,
+		req.Intitle,
+		req.Intext,
+		req.Filetype,
+		req.Ext,
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns entry of key; returns ErrCacheMiss if not found or expired
+func (c *Cache) Get(key string) (*CacheEntry, error) {
+	b, err := os.ReadFile(c.path(key, ".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &CacheEntry{}
+	if err := json.Unmarshal(b, entry); err != nil {
+		return nil, ErrCacheMiss
+	}
+
+	if c.TTL > 0 && time.Since(entry.Time) > c.TTL {
+		return nil, ErrCacheMiss
+	}
+
+	entry.Body, err = os.ReadFile(c.path(key, ".html"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Put writes entry to cache; html is written before metadata, so a partially
+// written entry is not used
+func (c *Cache) Put(entry *CacheEntry) error {
+	meta, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(c.Dir, entry.Key[:2]), 0o700); err != nil {
+		return err
+	}
+
+	if err := writeFile(c.path(entry.Key, ".html"), entry.Body); err != nil {
+		return err
+	}
+
+	return writeFile(c.path(entry.Key, ".json"), meta)
+}
+
+func (c *Cache) path(key, ext string) string {
+	return filepath.Join(c.Dir, key[:2], key+ext)
+}
+
+// Response returns entry as a response of a request bound to ctx
+func (e *CacheEntry) Response(ctx context.Context) *http.Response {
+	req := &http.Request{Method: "GET", Header: http.Header{}}
+	req.URL, _ = url.Parse(e.URL)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req.WithContext(ctx),
+	}
+}
+
+// writeFile writes b to filename atomically
+func writeFile(filename string, b []byte) error {
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filename)
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awolverp/dorkali"
+)
+
+const batchUsageText = "Usage: %s batch -e ENGINE -f FILE [OPTIONS]\n\n" +
+	"Searches each line of FILE. lines are '[SEARCH OPTIONS] QUERY', and search options\n" +
+	"passed to batch are defaults of lines. empty lines and lines starting with '#' are ignored.\n\n" +
+	"Example line:\n" +
+	"\t-n 20 -filetype pdf \"annual report\" site:example.com\n\n" +
+	"*Batch Options:\n" +
+	"\t-e ENGINE           Engine to search in. (required)\n" +
+	"\t-f FILE             File of dorks, one per line. '-' reads from stdin. (required)\n" +
+	"\t-delay DURATION     Minimum delay between requests; same as -rate 1/DURATION. (default 2s)\n" +
+	"\t-j NUMBER           Number of dorks to search concurrently. (default 1)\n" +
+	"\t-checkpoint FILE    Record progress (done dorks, fetched pages and emitted results) in FILE.\n" +
+	"\t-resume FILE        Continue from checkpoint FILE, without repeating emitted records.\n" +
+	"\t                    progress is recorded in FILE too, unless -checkpoint is passed.\n" +
+	"\t                    use with ndjson, csv, url or template output, and append to previous output.\n\n"
+
+type batchOptions struct {
+	Engine string
+	File   string
+	Delay  time.Duration
+	Jobs   int
+
+	Checkpoint string
+	Resume     string
+}
+
+// PrintBatchUsage prints usage of batch command
+func PrintBatchUsage() {
+	fmt.Printf(batchUsageText, os.Args[0])
+	fmt.Print(outputUsageText + searchUsageText + sessionUsageText + cacheUsageText)
+	fmt.Printf("Use '%s help ENGINE' to see engine options.\n", os.Args[0])
+}
+
+// RunBatch runs batch command, and returns exit code
+func RunBatch(args []string) int {
+	name := engineFromArgs(args)
+	if name == "" {
+		fmt.Fprintf(os.Stderr, "error: -e is required. use '%s help batch' to see information\n", os.Args[0])
+		return 1
+	}
+
+	engine := UseEngineOrExit(name)
+
+	opts := defaultSearchOptions()
+	bopts := &batchOptions{Delay: time.Second * 2, Jobs: 1}
+
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	fs.Usage = func() { fmt.Printf("Use '%s help batch' to see help information.\n", os.Args[0]) }
+
+	fs.StringVar(&bopts.Engine, "e", "", "")               // engine
+	fs.StringVar(&bopts.File, "f", "", "")                 // file
+	fs.DurationVar(&bopts.Delay, "delay", bopts.Delay, "") // delay
+	fs.IntVar(&bopts.Jobs, "j", bopts.Jobs, "")            // jobs
+	fs.StringVar(&bopts.Checkpoint, "checkpoint", "", "")  // checkpoint
+	fs.StringVar(&bopts.Resume, "resume", "", "")          // resume
+	outputFlags(fs, &opts.Output)
+	applyStart := searchFlags(fs, opts.Request, &opts.Limit)
+	sessionFlags(fs, &opts.Session)
+	cacheFlags(fs, &opts.Cache)
+	engine.Flags(fs)
+
+	fs.Parse(args)
+
+	if err := applyStart(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	if bopts.File == "" {
+		fmt.Fprintf(os.Stderr, "error: -f is required. use '%s help batch' to see information\n", os.Args[0])
+		return 1
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "error: unexpected arguments %q. queries are read from -f\n", fs.Args())
+		return 1
+	}
+
+	// appended output of json format would be a second array
+	if bopts.Resume != "" && opts.Output.Template == "" && opts.Output.TemplateFile == "" && strings.ToLower(opts.Output.Format) == "json" {
+		fmt.Fprintf(os.Stderr, "error: -resume can't be used with json output; use ndjson instead\n")
+		return 1
+	}
+
+	if bopts.Jobs < 1 {
+		bopts.Jobs = 1
+	}
+
+	if opts.Session.Rate == "" && bopts.Delay > 0 {
+		opts.Session.Rate = "1/" + bopts.Delay.String()
+	}
+
+	dorks, err := readDorks(bopts.File)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	checkpoint, err := openCheckpoint(bopts, engine.Name())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	// previous output has the header
+	opts.Output.NoHeader = bopts.Resume != ""
+
+	w, err := NewRecordWriter(os.Stdout, &opts.Output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	if err := engine.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	session, err := OpenSession(&opts.Session)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	engine.SetSession(session)
+
+	cache, err := OpenCache(&opts.Cache)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	engine.SetCache(cache)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sw := &syncWriter{w: w}
+
+	jobs := make(chan string)
+	failed := 0
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	for i := 0; i < bopts.Jobs; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for dork := range jobs {
+				if err := searchDork(ctx, engine, dork, opts, sw, checkpoint); err != nil {
+					fmt.Fprintf(os.Stderr, "error on %q: %s\n", dork, err.Error())
+
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, dork := range dorks {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- dork
+	}
+
+	close(jobs)
+	wg.Wait()
+	sw.Close()
+
+	if err := CloseSession(session, &opts.Session); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	if ctx.Err() != nil || failed != 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// openCheckpoint returns checkpoint of batch, or nil if not wanted
+func openCheckpoint(bopts *batchOptions, engine string) (*Checkpoint, error) {
+	if bopts.Resume == "" {
+		if bopts.Checkpoint == "" {
+			return nil, nil
+		}
+		return NewCheckpoint(bopts.Checkpoint, engine), nil
+	}
+
+	checkpoint, err := LoadCheckpoint(bopts.Resume)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkpoint.Engine != engine {
+		return nil, fmt.Errorf("checkpoint %q is for engine %q, not %q", bopts.Resume, checkpoint.Engine, engine)
+	}
+
+	if bopts.Checkpoint != "" {
+		checkpoint.SetPath(bopts.Checkpoint)
+	}
+
+	return checkpoint, nil
+}
+
+// searchDork parses dork and searches it; checkpoint may be nil
+func searchDork(ctx context.Context, engine *dorkali.API, dork string, defaults *searchOptions, w RecordWriter, checkpoint *Checkpoint) error {
+	req, limit, err := parseDork(dork, defaults)
+	if err != nil {
+		return err
+	}
+
+	if checkpoint == nil {
+		paginator := dorkali.NewPaginator(engine, req, limit)
+		return Search(ctx, paginator, &Record{Engine: engine.Name(), Query: req.Query, Dork: dork}, w, nil)
+	}
+
+	state := checkpoint.State(dork)
+	if state != nil && state.Done {
+		return nil
+	}
+
+	if state != nil {
+		req.Page = state.Page
+	}
+
+	paginator := dorkali.NewPaginator(engine, req, limit)
+
+	if state != nil {
+		paginator.Resume(state.Urls)
+	}
+
+	err = Search(ctx, paginator, &Record{Engine: engine.Name(), Query: req.Query, Dork: dork}, w, func(page int, records []*Record) error {
+		return checkpoint.Update(dork, func(s *DorkState) {
+			s.Page = page + 1
+			s.Pages++
+			s.Results += len(records)
+			for _, r := range records {
+				s.Urls = append(s.Urls, r.Url)
+			}
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkpoint.Update(dork, func(s *DorkState) {
+		s.Done = true
+		s.Page = paginator.Page()
+	})
+}
+
+// parseDork parses a dork line as search options; defaults are not changed.
+//
+// query is the rest of line after options as is, so quotes of query are kept.
+func parseDork(dork string, defaults *searchOptions) (*dorkali.SearchRequest, int, error) {
+	args, offsets, err := splitArgs(dork)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req := defaults.Request.Clone()
+	limit := defaults.Limit
+
+	fs := flag.NewFlagSet("dork", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	applyStart := searchFlags(fs, req, &limit)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, 0, err
+	}
+
+	if err := applyStart(); err != nil {
+		return nil, 0, err
+	}
+
+	if n := fs.NArg(); n != 0 {
+		req.Query = strings.TrimSpace(dork[offsets[len(args)-n]:])
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	return req, limit, nil
+}
+
+// readDorks reads non-empty and non-comment lines of filename; "-" reads from stdin
+func readDorks(filename string) ([]string, error) {
+	var r io.Reader = os.Stdin
+
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	var dorks []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		dorks = append(dorks, line)
+	}
+
+	return dorks, scanner.Err()
+}
+
+// engineFromArgs returns value of -e option
+func engineFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+
+		if name == "e" && i+1 < len(args) {
+			return args[i+1]
+		}
+
+		if strings.HasPrefix(name, "e=") {
+			return name[2:]
+		}
+	}
+
+	return ""
+}
+
+// splitArgs splits s into arguments like a shell does; supports quotes and backslash escapes.
+//
+// returns offsets of arguments in s too
+func splitArgs(s string) ([]string, []int, error) {
+	var (
+		args    []string
+		offsets []int
+		buf     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for i, c := range s {
+		if !inArg && c != ' ' && c != '\t' {
+			offsets = append(offsets, i)
+		}
+
+		switch {
+		case escaped:
+			buf.WriteRune(c)
+			escaped = false
+
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				buf.WriteRune(c)
+			}
+
+		case c == '"' || c == '\'':
+			quote = c
+			inArg = true
+
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, buf.String())
+				buf.Reset()
+				inArg = false
+			}
+
+		default:
+			buf.WriteRune(c)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, nil, errors.New("unterminated quote or escape")
+	}
+
+	if inArg {
+		args = append(args, buf.String())
+	}
+
+	return args, offsets, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/awolverp/dorkali"
+)
+
+const cacheUsageText = "*Cache Options:\n" +
+	"\t-cache-dir DIR      Directory of cached responses. (default 'dorkali/responses' in user cache directory)\n" +
+	"\t-cache-ttl DURATION Time which cached responses are used. (default 24h)\n" +
+	"\t-no-cache           Don't read and write cached responses.\n" +
+	"\t-refresh            Don't read cached responses, but cache new responses.\n" +
+	"\t-offline            Use only cached responses, and fail on requests which are not cached.\n\n"
+
+type cacheOptions struct {
+	Dir     string
+	TTL     time.Duration
+	NoCache bool
+	Refresh bool
+	Offline bool
+}
+
+func cacheFlags(fs *flag.FlagSet, o *cacheOptions) {
+	fs.StringVar(&o.Dir, "cache-dir", o.Dir, "")   // cache directory
+	fs.DurationVar(&o.TTL, "cache-ttl", o.TTL, "") // cache ttl
+	fs.BoolVar(&o.NoCache, "no-cache", false, "")  // no cache
+	fs.BoolVar(&o.Refresh, "refresh", false, "")   // refresh
+	fs.BoolVar(&o.Offline, "offline", false, "")   // offline
+}
+
+// OpenCache returns cache of options, or nil if cache is disabled
+func OpenCache(o *cacheOptions) (*dorkali.Cache, error) {
+	if o.NoCache {
+		if o.Offline {
+			return nil, errors.New("-offline needs cache; don't pass -no-cache")
+		}
+		return nil, nil
+	}
+
+	if o.Dir == "" {
+		if o.Offline {
+			return nil, errors.New("-offline needs cache; pass -cache-dir")
+		}
+		return nil, nil
+	}
+
+	cache := dorkali.NewCache(o.Dir, o.TTL)
+	cache.Refresh = o.Refresh
+	cache.Offline = o.Offline
+
+	return cache, nil
+}
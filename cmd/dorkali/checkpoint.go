@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Checkpoint records progress of a batch run, to resume it later
+type Checkpoint struct {
+	mu   sync.Mutex
+	path string
+
+	// Engine name of batch
+	Engine string `json:"engine"`
+
+	// Progress of dorks; key is the dork line
+	Dorks map[string]*DorkState `json:"dorks"`
+}
+
+// DorkState is progress of a dork
+type DorkState struct {
+	// Done is true if all pages of dork are fetched
+	Done bool `json:"done"`
+
+	// Page is next page to fetch
+	Page int `json:"page"`
+
+	// Pages is number of fetched pages
+	Pages int `json:"pages"`
+
+	// Results is number of emitted records
+	Results int `json:"results"`
+
+	// Urls of emitted records
+	Urls []string `json:"urls,omitempty"`
+}
+
+// NewCheckpoint returns an empty checkpoint which is saved to path
+func NewCheckpoint(path, engine string) *Checkpoint {
+	return &Checkpoint{path: path, Engine: engine, Dorks: make(map[string]*DorkState)}
+}
+
+// LoadCheckpoint reads checkpoint from path
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Checkpoint{path: path}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+
+	if c.Dorks == nil {
+		c.Dorks = make(map[string]*DorkState)
+	}
+
+	return c, nil
+}
+
+// SetPath changes file which checkpoint is saved to
+func (c *Checkpoint) SetPath(path string) {
+	c.mu.Lock()
+	c.path = path
+	c.mu.Unlock()
+}
+
+// State returns copy of dork progress, or nil if dork is not started
+func (c *Checkpoint) State(dork string) *DorkState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.Dorks[dork]
+	if !ok {
+		return nil
+	}
+
+	cp := *s
+	cp.Urls = append([]string(nil), s.Urls...)
+	return &cp
+}
+
+// Update changes progress of dork by f, and saves checkpoint
+func (c *Checkpoint) Update(dork string, f func(s *DorkState)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.Dorks[dork]
+	if !ok {
+		s = &DorkState{}
+		c.Dorks[dork] = s
+	}
+
+	f(s)
+
+	return c.save()
+}
+
+// save writes checkpoint to a temporary file and renames it, so an
+// interrupted write doesn't corrupt the checkpoint
+func (c *Checkpoint) save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".dorkali-checkpoint-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
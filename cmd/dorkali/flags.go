@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awolverp/dorkali"
+)
+
+const searchUsageText = "*Request Options:\n" +
+	"\t-t DURATION         Maximum time allowed for connection / e.g. 10s, 1m, ... . (default 20s)\n" +
+	"\t-H HEADER           Pass custom header(s) to engine.\n" +
+	"\t                    Usage: ... -H 'KEY1: VALUE1' -H 'KEY2: VALUE2'\n" +
+	"\t-C COOKIE           Send cookie(s) to engine.\n" +
+	"\t                    Usage: ... -C 'KEY=VALUE' -C 'KEY2=VALUE2'\n\n" +
+	"*Search Options:\n" +
+	"\t-n NUMBER           Number of results; fetches next pages until collected. (default 10)\n" +
+	"\t-count NUMBER       Number of results per page. (default 10)\n" +
+	"\t-page NUMBER        Page of results to start from, starts from zero. (default 0)\n" +
+	"\t-safe               Safe search. (default false)\n" +
+	"\t-lang LANGUAGE      Language. e.g. en, de, fr, ...\n" +
+	"\t-region REGION      Country or region to focus the search on. e.g. us, de, fr, ...\n" +
+	"\t-country REGION     Same as -region.\n" +
+	"\t-start NUMBER       Offset of first result; same as -page NUMBER/COUNT, rounded down.\n" +
+	"\t-time RANGE         Limit results to past hour, day, week, month or year.\n\n" +
+	"*Query Helpers:\n" +
+	"\t-site TEXT          ... site:\"TEXT\"\n" +
+	"\t-inurl TEXT         ... inurl:\"TEXT\"\n" +
+	"\t-intitle TEXT       ... intitle:\"TEXT\"\n" +
+	"\t-intext TEXT        ... intext:\"TEXT\"\n" +
+	"\t-filetype TEXT      ... filetype:\"TEXT\"\n" +
+	"\t-ext TEXT           ... ext:\"TEXT\"\n\n"
+
+// PrintEngineUsage prints output options, search options and engine specific options
+func PrintEngineUsage(engine *dorkali.API) {
+	fmt.Printf("Usage: %s %s [OPTIONS] QUERY\n\n", os.Args[0], engine.Name())
+	fmt.Print(outputUsageText + searchUsageText + sessionUsageText + cacheUsageText)
+	engine.Usage()
+}
+
+// searchOptions are options of a search
+type searchOptions struct {
+	Request *dorkali.SearchRequest
+
+	// Number of results
+	Limit int
+
+	Output outputOptions
+
+	Session sessionOptions
+
+	Cache cacheOptions
+}
+
+// searchFlags defines search options of req on fs, and number of results on limit;
+// current values are used as defaults.
+//
+// returns a function which applies -start to page of req, when number of results
+// per page is known; call it after fs is parsed
+func searchFlags(fs *flag.FlagSet, req *dorkali.SearchRequest, limit *int) func() error {
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	start := 0
+
+	fs.DurationVar(&req.Timeout, "t", req.Timeout, "")        // timeout
+	fs.Var(headerFlag{req.Header}, "H", "")                   // headers
+	fs.Var(&cookieFlag{&req.Cookies}, "C", "")                // cookies
+	fs.IntVar(limit, "n", *limit, "")                         // num
+	fs.IntVar(&req.Count, "count", req.Count, "")             // count
+	fs.IntVar(&req.Page, "page", req.Page, "")                // page
+	fs.BoolVar(&req.SafeSearch, "safe", req.SafeSearch, "")   // safe
+	fs.StringVar(&req.Language, "lang", req.Language, "")     // lang
+	fs.StringVar(&req.Region, "region", req.Region, "")       // region
+	fs.StringVar(&req.Region, "country", req.Region, "")      // region (alias)
+	fs.IntVar(&start, "start", 0, "")                         // start (offset of page)
+	fs.Var(&timeRangeFlag{&req.TimeRange}, "time", "")        // time range
+	fs.StringVar(&req.Site, "site", req.Site, "")             // site
+	fs.StringVar(&req.Inurl, "inurl", req.Inurl, "")          // inurl
+	fs.StringVar(&req.Intitle, "intitle", req.Intitle, "")    // intitle
+	fs.StringVar(&req.Intext, "intext", req.Intext, "")       // intext
+	fs.StringVar(&req.Filetype, "filetype", req.Filetype, "") // filetype
+	fs.StringVar(&req.Ext, "ext", req.Ext, "")                // ext
+
+	return func() error {
+		var err error
+
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name != "start" {
+				return
+			}
+
+			if start < 0 {
+				err = fmt.Errorf("invalid start %d", start)
+				return
+			}
+
+			req.Page = start / req.PerPage()
+		})
+
+		return err
+	}
+}
+
+// defaultSearchOptions returns search options with default values
+func defaultSearchOptions() *searchOptions {
+	return &searchOptions{
+		Request: &dorkali.SearchRequest{Timeout: time.Second * 20},
+		Limit:   dorkali.DefaultCount,
+		Session: sessionOptions{File: cacheFile("session.json"), RateState: cacheFile("ratelimit.json")},
+		Cache:   cacheOptions{Dir: cacheFile("responses"), TTL: dorkali.DefaultCacheTTL},
+	}
+}
+
+// ParseSearchFlags parses args as output, search and engine options
+func ParseSearchFlags(engine *dorkali.API, args []string) (*searchOptions, error) {
+	opts := defaultSearchOptions()
+	req := opts.Request
+
+	fs := flag.NewFlagSet(engine.Name(), flag.ExitOnError)
+	fs.Usage = func() { fmt.Printf("Use '%s help %s' to see help information.\n", os.Args[0], engine.Name()) }
+
+	outputFlags(fs, &opts.Output)
+	applyStart := searchFlags(fs, req, &opts.Limit)
+	sessionFlags(fs, &opts.Session)
+	cacheFlags(fs, &opts.Cache)
+	engine.Flags(fs)
+
+	fs.Parse(args)
+
+	if err := applyStart(); err != nil {
+		return nil, err
+	}
+
+	req.Query = strings.Join(fs.Args(), " ")
+
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s. use '%s help %s' to see information", err.Error(), os.Args[0], engine.Name())
+	}
+
+	return opts, nil
+}
+
+// headerFlag collects 'KEY: VALUE' headers
+type headerFlag struct {
+	h http.Header
+}
+
+func (f headerFlag) Set(s string) error {
+	values := strings.SplitN(s, ":", 2)
+	if len(values) != 2 {
+		return fmt.Errorf("invalid header %q", s)
+	}
+
+	f.h.Add(strings.TrimSpace(values[0]), strings.TrimSpace(values[1]))
+	return nil
+}
+
+func (f headerFlag) String() string {
+	return ""
+}
+
+// cookieFlag collects 'KEY=VALUE' cookies
+type cookieFlag struct {
+	c *[]*http.Cookie
+}
+
+func (f *cookieFlag) Set(s string) error {
+	values := strings.SplitN(s, "=", 2)
+	if len(values) != 2 {
+		return fmt.Errorf("invalid cookie %q", s)
+	}
+
+	*f.c = append(*f.c, &http.Cookie{Name: strings.TrimSpace(values[0]), Value: strings.TrimSpace(values[1])})
+	return nil
+}
+
+func (f *cookieFlag) String() string {
+	return ""
+}
+
+type timeRangeFlag struct {
+	t *dorkali.TimeRange
+}
+
+func (f *timeRangeFlag) Set(s string) (err error) {
+	*f.t, err = dorkali.ParseTimeRange(s)
+	return
+}
+
+func (f *timeRangeFlag) String() string {
+	return ""
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
+	"time"
 
 	"github.com/awolverp/dorkali"
+	_ "github.com/awolverp/dorkali/bing"
+	_ "github.com/awolverp/dorkali/duckduckgo"
 	_ "github.com/awolverp/dorkali/google"
 )
 
@@ -14,12 +19,16 @@ const (
 	VersionMesssage = "dorkali " + Version + " ( by awolverp ) / %s\n"
 	UsageMessage    = "Dorkali a program written in golang to dorks queries in search engines\n\n" +
 		"Usage:\n" +
-		"\t%s [list | version [engineName] | help [engineName]]\n" +
-		"\t%s engineName [OPTIONS]\n\n" +
+		"\t%s [list | version [engineName] | help [engineName | batch | parse]]\n" +
+		"\t%s engineName [OPTIONS]\n" +
+		"\t%s batch -e engineName -f FILE [OPTIONS]\n" +
+		"\t%s parse engineName [OPTIONS] FILE...\n\n" +
 		"*Commands:\n" +
 		"\tversion [engineName]   print version, or engine version if pass engineName, and exit\n" +
 		"\tlist                   print list of engines and exit\n" +
-		"\thelp [engineName]      print this help, or print engine help if pass engineName, and exit\n"
+		"\thelp [engineName]      print this help, or print engine help if pass engineName, and exit\n" +
+		"\tbatch                  search dorks of a file, one per line. see 'help batch'\n" +
+		"\tparse                  parse saved html pages of an engine. see 'help parse'\n"
 )
 
 var engine *dorkali.API = nil
@@ -27,7 +36,7 @@ var engine *dorkali.API = nil
 func main() {
 
 	if len(os.Args) < 2 {
-		fmt.Printf(UsageMessage, os.Args[0], os.Args[0])
+		fmt.Printf(UsageMessage, os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 		return
 	}
 
@@ -53,38 +62,128 @@ func main() {
 
 	// help
 	case "help":
+		if len(os.Args) == 3 && os.Args[2] == "batch" {
+			PrintBatchUsage()
+			return
+		}
+
+		if len(os.Args) == 3 && os.Args[2] == "parse" {
+			PrintParseUsage()
+			return
+		}
+
 		if len(os.Args) == 3 {
-			UseEngineOrExit(os.Args[2]).Usage()
+			PrintEngineUsage(UseEngineOrExit(os.Args[2]))
 			return
 		}
 
-		fmt.Printf(UsageMessage, os.Args[0], os.Args[0])
+		fmt.Printf(UsageMessage, os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 		return
 
+	// batch
+	case "batch":
+		os.Exit(RunBatch(os.Args[2:]))
+
+	// parse
+	case "parse":
+		os.Exit(RunParse(os.Args[2:]))
+
 	// Use engine
 	default:
 		engine = UseEngineOrExit(os.Args[1])
 	}
 
+	opts, err := ParseSearchFlags(engine, os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	w, err := NewRecordWriter(os.Stdout, &opts.Output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
 	if err := engine.Start(); err != nil {
-		fmt.Printf("error: %s\n", err.Error())
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
 		os.Exit(1)
 	}
 
-	response, err := engine.Search(os.Args[2:])
+	session, err := OpenSession(&opts.Session)
 	if err != nil {
-		fmt.Printf("error on search: %s\n", err.Error())
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
 		os.Exit(1)
 	}
 
-	results, err := engine.ParseResponse(response)
+	engine.SetSession(session)
+
+	cache, err := OpenCache(&opts.Cache)
 	if err != nil {
-		fmt.Printf("error on parsing: %s\n", err.Error())
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
 		os.Exit(1)
 	}
 
-	for _, r := range results {
-		fmt.Println(r.Url())
+	engine.SetCache(cache)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	paginator := dorkali.NewPaginator(engine, opts.Request, opts.Limit)
+
+	err = Search(ctx, paginator, &Record{Engine: engine.Name(), Query: opts.Request.Query}, w, nil)
+	w.Close()
+
+	if err := CloseSession(session, &opts.Session); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error on search: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// Search fetches next pages of paginator until it's done, and writes results to w.
+//
+// base is used to fill engine, query and dork of records. onPage (if not nil)
+// is called with records of each page before they are written (e.g. to save a
+// checkpoint), so a page is not written again on resume; if it returns error,
+// records are not written.
+func Search(ctx context.Context, paginator *dorkali.Paginator, base *Record, w RecordWriter, onPage func(page int, records []*Record) error) error {
+	for {
+		page := paginator.Page()
+
+		results, err := paginator.Next(ctx)
+		if err == dorkali.ErrNoMoreResults {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		position := paginator.Count() - len(results)
+		records := make([]*Record, 0, len(results))
+
+		for _, r := range results {
+			position++
+
+			records = append(records, NewRecord(base, page, position, r, now))
+		}
+
+		if onPage != nil {
+			if err := onPage(page, records); err != nil {
+				return err
+			}
+		}
+
+		for _, rec := range records {
+			if err := w.Write(rec); err != nil {
+				return err
+			}
+		}
 	}
 }
 
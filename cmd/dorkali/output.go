@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awolverp/dorkali"
+)
+
+const outputUsageText = "*Output Options:\n" +
+	"\t-o, -format FORMAT  Output format: url, json, ndjson or csv. (default url)\n" +
+	"\t                    json: pretty JSON array, ndjson: one JSON object per line (streamed),\n" +
+	"\t                    csv: CSV with header row, url: one url per line.\n" +
+	templateUsageText
+
+// Record is an output record of a result
+type Record struct {
+	Engine      string    `json:"engine"`
+	Query       string    `json:"query"`
+	Dork        string    `json:"dork,omitempty"`
+	Page        int       `json:"page"`
+	Position    int       `json:"position"`
+	Url         string    `json:"url"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Time        time.Time `json:"fetched_at"`
+
+	// Result is the parsed result, for templates
+	Result dorkali.Result `json:"-"`
+}
+
+// NewRecord returns copy of base (which has engine, query and dork) filled by r
+func NewRecord(base *Record, page, position int, r dorkali.Result, t time.Time) *Record {
+	rec := *base
+
+	rec.Page = page
+	rec.Position = position
+	rec.Url = r.Url()
+	rec.Title = strings.TrimSpace(r.Title())
+	rec.Description = strings.TrimSpace(r.Description())
+	rec.Time = t
+	rec.Result = r
+
+	return &rec
+}
+
+// RecordWriter writes records in a format
+type RecordWriter interface {
+	// Write writes a record
+	Write(r *Record) error
+
+	// Close writes remaining data; it doesn't close the underlying writer
+	Close() error
+}
+
+type outputOptions struct {
+	Format string
+
+	Template     string
+	TemplateFile string
+
+	// NoHeader disables header row of csv; used when appending to previous output
+	NoHeader bool
+}
+
+// outputFlags defines output options of o on fs
+func outputFlags(fs *flag.FlagSet, o *outputOptions) {
+	fs.StringVar(&o.Format, "o", "url", "")                // format
+	fs.StringVar(&o.Format, "format", "url", "")           // format
+	fs.StringVar(&o.Template, "template", "", "")          // template
+	fs.StringVar(&o.TemplateFile, "template-file", "", "") // template file
+}
+
+// NewRecordWriter returns writer of format
+//
+// template options overrides format
+func NewRecordWriter(w io.Writer, o *outputOptions) (RecordWriter, error) {
+	if o.Template != "" {
+		return newTemplateWriter(w, o.Template)
+	}
+
+	if o.TemplateFile != "" {
+		return newTemplateFileWriter(w, o.TemplateFile)
+	}
+
+	switch strings.ToLower(o.Format) {
+	case "", "url", "urls", "plain":
+		return &urlWriter{w}, nil
+	case "json":
+		return &jsonWriter{w: w}, nil
+	case "ndjson", "jsonl":
+		return &ndjsonWriter{json.NewEncoder(w)}, nil
+	case "csv":
+		return &csvWriter{w: csv.NewWriter(w), header: o.NoHeader}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output format %q", o.Format)
+}
+
+// urlWriter writes one url per line
+type urlWriter struct {
+	w io.Writer
+}
+
+func (uw *urlWriter) Write(r *Record) error {
+	_, err := fmt.Fprintln(uw.w, r.Url)
+	return err
+}
+
+func (uw *urlWriter) Close() error {
+	return nil
+}
+
+// jsonWriter writes a pretty JSON array; records are written as they arrive
+type jsonWriter struct {
+	w io.Writer
+	n int
+}
+
+func (jw *jsonWriter) Write(r *Record) error {
+	b, err := json.MarshalIndent(r, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if jw.n == 0 {
+		sep = "[\n  "
+	}
+	jw.n++
+
+	_, err = io.WriteString(jw.w, sep+string(b))
+	return err
+}
+
+func (jw *jsonWriter) Close() error {
+	if jw.n == 0 {
+		_, err := io.WriteString(jw.w, "[]\n")
+		return err
+	}
+
+	_, err := io.WriteString(jw.w, "\n]\n")
+	return err
+}
+
+// ndjsonWriter writes one JSON object per line
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (nw *ndjsonWriter) Write(r *Record) error {
+	return nw.enc.Encode(r)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
+
+// csvWriter writes CSV with a header row
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+var csvHeader = []string{"engine", "query", "dork", "page", "position", "url", "title", "description", "fetched_at"}
+
+func (cw *csvWriter) Write(r *Record) error {
+	if !cw.header {
+		cw.header = true
+		if err := cw.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	err := cw.w.Write([]string{
+		r.Engine, r.Query, r.Dork, strconv.Itoa(r.Page), strconv.Itoa(r.Position),
+		r.Url, r.Title, r.Description, r.Time.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	if !cw.header {
+		cw.header = true
+		cw.w.Write(csvHeader)
+	}
+
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// syncWriter makes a RecordWriter safe for concurrent use
+type syncWriter struct {
+	mu sync.Mutex
+	w  RecordWriter
+}
+
+func (sw *syncWriter) Write(r *Record) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	return sw.w.Write(r)
+}
+
+func (sw *syncWriter) Close() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	return sw.w.Close()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/awolverp/dorkali"
+)
+
+const parseUsageText = "Usage: %s parse ENGINE [OPTIONS] FILE...\n\n" +
+	"Parses saved html pages of ENGINE (e.g. by -save-html), without sending requests,\n" +
+	"and writes their results. '-' (or no FILE) reads a page from stdin.\n" +
+	"dork of records is the file name, and fetched_at is modification time of the file.\n\n" +
+	"Example:\n" +
+	"\t%s parse google -o ndjson page1.html page2.html\n\n"
+
+// PrintParseUsage prints usage of parse command
+func PrintParseUsage() {
+	fmt.Printf(parseUsageText, os.Args[0], os.Args[0])
+	fmt.Print(outputUsageText)
+}
+
+// RunParse runs parse command, and returns exit code
+func RunParse(args []string) int {
+	if len(args) == 0 || args[0] == "" || args[0][0] == '-' {
+		fmt.Fprintf(os.Stderr, "error: ENGINE is required. use '%s help parse' to see information\n", os.Args[0])
+		return 1
+	}
+
+	engine := UseEngineOrExit(args[0])
+
+	opts := &outputOptions{}
+
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	fs.Usage = func() { fmt.Printf("Use '%s help parse' to see help information.\n", os.Args[0]) }
+
+	outputFlags(fs, opts)
+
+	fs.Parse(args[1:])
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	w, err := NewRecordWriter(os.Stdout, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	code := 0
+
+	for _, file := range files {
+		if err := parseFile(engine, file, w); err != nil {
+			fmt.Fprintf(os.Stderr, "error on %s: %s\n", file, err.Error())
+			code = 1
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	return code
+}
+
+// parseFile parses html page of file ('-' is stdin) by engine, and writes results to w
+func parseFile(engine *dorkali.API, file string, w RecordWriter) error {
+	h, t, err := readPage(file)
+	if err != nil {
+		return err
+	}
+
+	results, err := engine.ParseHTML(h)
+	if err != nil && err != dorkali.ErrNoMoreResults {
+		return err
+	}
+
+	base := &Record{Engine: engine.Name(), Dork: file}
+
+	for i, r := range results {
+		if err := w.Write(NewRecord(base, 0, i+1, r, t)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readPage returns content of file ('-' is stdin) and its modification time
+func readPage(file string) (string, time.Time, error) {
+	if file == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		return string(b), time.Now().UTC(), err
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return string(b), info.ModTime().UTC(), nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/awolverp/dorkali"
+)
+
+const sessionUsageText = "*Session Options:\n" +
+	"\t-v                  Set verbose.\n" +
+	"\t-profile NAME       Browser profile which its headers are sent: random, chrome-windows,\n" +
+	"\t                    chrome-macos, chrome-linux, edge-windows, firefox-windows, firefox-macos,\n" +
+	"\t                    firefox-linux or safari-macos. (default random)\n" +
+	"\t-proxy URL          Send requests through proxy: http://HOST:PORT, https://HOST:PORT\n" +
+	"\t                    or socks5://[USER:PASS@]HOST:PORT. (default from HTTP_PROXY/HTTPS_PROXY)\n" +
+	"\t-proxy-file FILE    Rotate requests between proxies of FILE, one per line. blocked proxies\n" +
+	"\t                    are not used until their cooldown is elapsed and they pass a health check.\n" +
+	"\t-rotate MODE        Rotation of -proxy-file: request (next proxy per request) or session\n" +
+	"\t                    (same proxy until it's blocked). (default request)\n" +
+	"\t-cooldown DURATION  Cooldown of blocked proxies. (default 10m)\n" +
+	"\t-rate RATE          Maximum rate of requests per engine: N/UNIT, unit is s, m, h, d or\n" +
+	"\t                    a duration. e.g. 10/m, 1/5s.\n" +
+	"\t-proxy-rate RATE    Maximum rate of requests per proxy.\n" +
+	"\t-jitter DURATION    Random delay up to DURATION before each request.\n" +
+	"\t-hourly NUMBER      Maximum requests per engine in an hour.\n" +
+	"\t-daily NUMBER       Maximum requests per engine in a day.\n" +
+	"\t-rate-state FILE    Keep used hourly and daily budgets in FILE between runs.\n" +
+	"\t                    (default 'dorkali/ratelimit.json' in user cache directory)\n" +
+	"\t-session FILE       Load cookies from FILE, and save them to it at exit.\n" +
+	"\t                    (default 'dorkali/session.json' in user cache directory)\n" +
+	"\t-no-session         Don't load and save session cookies.\n" +
+	"\t-import-cookies FILE\n" +
+	"\t                    Import cookies of a Netscape cookies.txt FILE. (e.g. exported from browser)\n" +
+	"\t-export-cookies FILE\n" +
+	"\t                    Export session cookies to FILE in Netscape cookies.txt format at exit.\n" +
+	"\t-save-html DIR      Save every fetched page in DIR: html body, and a json file of url, status,\n" +
+	"\t                    request and response headers. see 'help parse' to parse them again.\n\n" +
+	"*Retry Options:\n" +
+	"\t-retries NUMBER     Retries of requests which fail by network errors, status 429 or 5xx. (default 2)\n" +
+	"\t-backoff DURATION   Delay before first retry; doubled for next retries, with random jitter.\n" +
+	"\t                    Retry-After header of responses is respected. (default 1s)\n" +
+	"\t-max-backoff DURATION\n" +
+	"\t                    Maximum delay between retries; longer Retry-After is not retried. (default 1m)\n" +
+	"\t-retry-rotate LIST  Rotate proxy and/or profile between retries. e.g. proxy,profile\n\n"
+
+type sessionOptions struct {
+	Verbose bool
+	Profile string
+
+	Proxy     string
+	ProxyFile string
+	Rotate    string
+	Cooldown  time.Duration
+
+	Rate      string
+	ProxyRate string
+	Jitter    time.Duration
+	Hourly    int
+	Daily     int
+	RateState string
+
+	File      string
+	NoSession bool
+
+	Retries     int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	RetryRotate string
+
+	Import string
+	Export string
+
+	SaveHTML string
+}
+
+func sessionFlags(fs *flag.FlagSet, o *sessionOptions) {
+	fs.BoolVar(&o.Verbose, "v", false, "")                                      // verbose
+	fs.StringVar(&o.Profile, "profile", "random", "")                           // profile
+	fs.StringVar(&o.Proxy, "proxy", "", "")                                     // proxy
+	fs.StringVar(&o.ProxyFile, "proxy-file", "", "")                            // proxy file
+	fs.StringVar(&o.Rotate, "rotate", string(dorkali.RotateRequest), "")        // rotation
+	fs.DurationVar(&o.Cooldown, "cooldown", dorkali.DefaultCooldown, "")        // cooldown
+	fs.StringVar(&o.Rate, "rate", o.Rate, "")                                   // rate
+	fs.StringVar(&o.ProxyRate, "proxy-rate", "", "")                            // proxy rate
+	fs.DurationVar(&o.Jitter, "jitter", 0, "")                                  // jitter
+	fs.IntVar(&o.Hourly, "hourly", 0, "")                                       // hourly budget
+	fs.IntVar(&o.Daily, "daily", 0, "")                                         // daily budget
+	fs.StringVar(&o.RateState, "rate-state", o.RateState, "")                   // rate limit state
+	fs.IntVar(&o.Retries, "retries", 2, "")                                     // retries
+	fs.DurationVar(&o.Backoff, "backoff", dorkali.DefaultBackoff, "")           // backoff
+	fs.DurationVar(&o.MaxBackoff, "max-backoff", dorkali.DefaultMaxBackoff, "") // max backoff
+	fs.StringVar(&o.RetryRotate, "retry-rotate", "", "")                        // retry rotation
+	fs.StringVar(&o.File, "session", o.File, "")                                // session file
+	fs.BoolVar(&o.NoSession, "no-session", false, "")                           // no session
+	fs.StringVar(&o.Import, "import-cookies", "", "")                           // import cookies
+	fs.StringVar(&o.Export, "export-cookies", "", "")                           // export cookies
+	fs.StringVar(&o.SaveHTML, "save-html", "", "")                              // save html
+}
+
+// cacheFile returns path of name in dorkali directory of user cache directory,
+// or empty string if there's no cache directory
+func cacheFile(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "dorkali", name)
+}
+
+// OpenSession returns session of options, which has loaded and imported cookies
+func OpenSession(o *sessionOptions) (*dorkali.Session, error) {
+	session := dorkali.NewSession()
+	session.Verbose = o.Verbose
+
+	if o.Profile != "" {
+		profile, err := dorkali.GetProfile(o.Profile)
+		if err != nil {
+			return nil, err
+		}
+
+		session.SetProfile(profile)
+	}
+
+	if o.Proxy != "" {
+		proxy, err := dorkali.ParseProxy(o.Proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		session.SetProxy(proxy)
+	}
+
+	if o.SaveHTML != "" {
+		session.SetPageHook(dorkali.SavePages(o.SaveHTML))
+	}
+
+	limiter, err := newRateLimiter(o)
+	if err != nil {
+		return nil, err
+	}
+
+	if limiter != nil {
+		session.SetRateLimiter(limiter)
+	}
+
+	policy, err := newRetryPolicy(o)
+	if err != nil {
+		return nil, err
+	}
+
+	session.SetRetryPolicy(policy)
+
+	if o.ProxyFile != "" {
+		pool, err := dorkali.LoadProxyPool(o.ProxyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		switch r := dorkali.Rotation(o.Rotate); r {
+		case dorkali.RotateRequest, dorkali.RotateSession:
+			pool.Rotation = r
+		default:
+			return nil, fmt.Errorf("invalid rotation %q; use request or session", o.Rotate)
+		}
+
+		pool.Cooldown = o.Cooldown
+		session.SetProxyPool(pool)
+	}
+
+	if !o.NoSession && o.File != "" {
+		err := session.Jar.Load(o.File)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if o.Import != "" {
+		f, err := os.Open(o.Import)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		if err := session.Jar.ImportNetscape(f); err != nil {
+			return nil, fmt.Errorf("%s: %w", o.Import, err)
+		}
+	}
+
+	return session, nil
+}
+
+// newRetryPolicy returns retry policy of options
+func newRetryPolicy(o *sessionOptions) (*dorkali.RetryPolicy, error) {
+	policy := &dorkali.RetryPolicy{
+		Attempts:   o.Retries + 1,
+		Backoff:    o.Backoff,
+		MaxBackoff: o.MaxBackoff,
+	}
+
+	for _, name := range strings.Split(o.RetryRotate, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "proxy":
+			policy.RotateProxy = true
+		case "profile":
+			policy.RotateProfile = true
+		default:
+			return nil, fmt.Errorf("invalid -retry-rotate %q; use proxy, profile or both", name)
+		}
+	}
+
+	return policy, nil
+}
+
+// newRateLimiter returns rate limiter of options, or nil if no limit is set
+func newRateLimiter(o *sessionOptions) (*dorkali.RateLimiter, error) {
+	rate, err := dorkali.ParseRate(o.Rate)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyRate, err := dorkali.ParseRate(o.ProxyRate)
+	if err != nil {
+		return nil, err
+	}
+
+	if rate.IsZero() && proxyRate.IsZero() && o.Jitter <= 0 && o.Hourly <= 0 && o.Daily <= 0 {
+		return nil, nil
+	}
+
+	limiter := dorkali.NewRateLimiter(rate)
+	limiter.ProxyRate = proxyRate
+	limiter.Jitter = o.Jitter
+	limiter.Hourly = o.Hourly
+	limiter.Daily = o.Daily
+
+	if o.Hourly > 0 || o.Daily > 0 {
+		limiter.StateFile = o.RateState
+	}
+
+	return limiter, nil
+}
+
+// CloseSession saves and exports cookies of session, and prints statistics of
+// proxy pool if session is verbose
+func CloseSession(session *dorkali.Session, o *sessionOptions) error {
+	if pool := session.ProxyPool(); pool != nil && session.Verbose {
+		printProxyStats(pool.Stats())
+	}
+
+	if !o.NoSession && o.File != "" {
+		if err := session.Jar.Save(o.File); err != nil {
+			return err
+		}
+	}
+
+	if o.Export != "" {
+		f, err := os.OpenFile(o.Export, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+
+		if err := session.Jar.ExportNetscape(f); err != nil {
+			f.Close()
+			return err
+		}
+
+		return f.Close()
+	}
+
+	return nil
+}
+
+// printProxyStats prints statistics of proxy pool to stderr
+func printProxyStats(stats []dorkali.ProxyStats) {
+	fmt.Fprintln(os.Stderr, "|  proxies:")
+
+	for _, st := range stats {
+		state := "ok"
+		if st.Burnt {
+			state = "burnt until " + st.BurntUntil.Format(time.Kitchen)
+		}
+
+		fmt.Fprintf(os.Stderr, "|  %s requests=%d errors=%d blocks=%d latency=%s %s\n",
+			st.Proxy, st.Requests, st.Errors, st.Blocks, st.Latency.Round(time.Millisecond), state)
+	}
+}
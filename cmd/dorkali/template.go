@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const templateUsageText = "\t-template TEXT      Render each result with Go text/template. overrides -o.\n" +
+	"\t                    Usage: ... -template '{{.Position}}\\t{{.Url}}\\t{{.Title}}'\n" +
+	"\t                    Fields: .Engine .Query .Dork .Page .Position .Url .Title .Description .Time .Result\n" +
+	"\t                    Functions: truncate N, domain, host, path, jsonescape, csvescape\n" +
+	"\t-template-file FILE Like -template but reads template from FILE.\n\n"
+
+// templateFuncs are helper functions of templates
+var templateFuncs = template.FuncMap{
+	// truncate returns first n characters of s
+	//  {{.Title | truncate 20}}
+	"truncate": func(n int, s string) string {
+		r := []rune(s)
+		if n < 0 || len(r) <= n {
+			return s
+		}
+		return string(r[:n])
+	},
+
+	// domain returns hostname of url without "www." prefix
+	"domain": func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimPrefix(u.Hostname(), "www.")
+	},
+
+	// host returns host of url (and port if has)
+	"host": func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			return ""
+		}
+		return u.Host
+	},
+
+	// path returns path of url
+	"path": func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			return ""
+		}
+		return u.Path
+	},
+
+	// jsonescape escapes s to use inside JSON string
+	"jsonescape": func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b[1 : len(b)-1])
+	},
+
+	// csvescape quotes s if needed to use as CSV field
+	"csvescape": func(s string) string {
+		buf := bytes.Buffer{}
+		w := csv.NewWriter(&buf)
+		w.Write([]string{s})
+		w.Flush()
+		return strings.TrimRight(buf.String(), "\r\n")
+	},
+}
+
+// templateWriter renders each record with a template
+type templateWriter struct {
+	w io.Writer
+	t *template.Template
+}
+
+// newTemplateWriter parses text as template; `\t` and `\n` sequences
+// are replaced with tab and newline, to be usable in shell arguments.
+func newTemplateWriter(w io.Writer, text string) (*templateWriter, error) {
+	return parseTemplate(w, strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(text))
+}
+
+// newTemplateFileWriter reads template from file
+func newTemplateFileWriter(w io.Writer, filename string) (*templateWriter, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTemplate(w, string(b))
+}
+
+// parseTemplate parses text as template, and appends a newline if text doesn't end with it
+func parseTemplate(w io.Writer, text string) (*templateWriter, error) {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	t, err := template.New("record").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &templateWriter{w, t}, nil
+}
+
+func (tw *templateWriter) Write(r *Record) error {
+	return tw.t.Execute(tw.w, r)
+}
+
+func (tw *templateWriter) Close() error {
+	return nil
+}
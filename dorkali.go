@@ -1,8 +1,16 @@
 package dorkali
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sync"
+	"time"
 )
 
 type Result interface {
@@ -20,7 +28,7 @@ type Result interface {
 }
 
 type Engine interface {
-	// Use(...) call it when want to use it
+	// Use(...) call it when want to use it; it is called after options are set
 	Start() error
 
 	// Version returns engine version
@@ -29,26 +37,45 @@ type Engine interface {
 	// Description of engine
 	Description() string
 
-	// Usage prints usage of engine
+	// Flags defines engine specific options on fs
+	Flags(fs *flag.FlagSet)
+
+	// Usage prints usage of engine specific options
 	Usage()
 
-	// SearchContext searchs query and returns response
-	Search(query interface{}) (*http.Response, error)
+	// Search searchs request and returns response
+	Search(req *SearchRequest) (*http.Response, error)
+
+	// SearchContext is like Search(...) but request is bound to ctx
+	SearchContext(ctx context.Context, req *SearchRequest) (*http.Response, error)
 
 	// ParseResponse parses returned response from .Search(...) method
+	//
+	// returns ErrNoMoreResults (with results of the page) if it's the last page
 	ParseResponse(response *http.Response) ([]Result, error)
 
+	// ParseResponseContext is like ParseResponse(...) but stops reading response when ctx is done
+	ParseResponseContext(ctx context.Context, response *http.Response) ([]Result, error)
+
 	// ParseHTML parses html responsed from google
 	ParseHTML(h string) ([]Result, error)
+
+	// SetSession sets session which engine sends requests with
+	SetSession(s *Session)
+
+	// Session returns session of engine
+	Session() *Session
 }
 
+var _ Engine = (*API)(nil)
+
 var engines = make(map[string]func() Engine)
 
 // register engine
 func RegisterEngine(name string, new_engine func() Engine) {
 	switch name {
-	case "version", "help", "list":
-		panic(name + ` is a engine name? you dont use these names: "version", "help", "list"`)
+	case "version", "help", "list", "batch", "parse":
+		panic(name + ` is a engine name? you dont use these names: "version", "help", "list", "batch", "parse"`)
 	}
 
 	engines[name] = new_engine
@@ -66,6 +93,12 @@ func Engines() []string {
 type API struct {
 	name string
 	e    Engine
+
+	cache *Cache
+
+	// responses which are cached after they're parsed successfully
+	mu      sync.Mutex
+	pending map[*http.Response]*CacheEntry
 }
 
 func (a *API) Name() string {
@@ -81,14 +114,196 @@ func (a *API) Version() string {
 	return a.e.Version()
 }
 
-// Search searchs query and returns response
-func (a *API) Search(query interface{}) (*http.Response, error) {
-	return a.e.Search(query)
+// Description of engine
+func (a *API) Description() string {
+	return a.e.Description()
+}
+
+// Flags defines engine specific options on fs
+func (a *API) Flags(fs *flag.FlagSet) {
+	a.e.Flags(fs)
+}
+
+// Search searchs request and returns response
+func (a *API) Search(req *SearchRequest) (*http.Response, error) {
+	return a.SearchContext(context.Background(), req)
+}
+
+// SearchContext is like Search(...) but request is bound to ctx.
+//
+// requests are throttled by rate limiter of session as requests of engine, and
+// if session has a proxy pool, the proxy which is blocked is burnt.
+//
+// if API has a cache, cached response of req is returned, and new response
+// is cached when it's parsed by ParseResponseContext(...) successfully.
+func (a *API) SearchContext(ctx context.Context, req *SearchRequest) (*http.Response, error) {
+	var key string
+
+	if a.cache != nil {
+		if err := req.Validate(); err != nil {
+			return nil, err
+		}
+
+		key = a.cacheKey(req)
+
+		if a.cache.Offline || !a.cache.Refresh {
+			entry, err := a.cache.Get(key)
+			if err == nil {
+				a.logf("|  cache: %s ( %s )\n\n", entry.URL, entry.Time.Format(time.RFC3339))
+				return entry.Response(context.WithValue(ctx, searchRequestKey{}, req)), nil
+			}
+
+			if err != ErrCacheMiss {
+				return nil, err
+			}
+		}
+
+		if a.cache.Offline {
+			return nil, fmt.Errorf("%w ( %s: %q page %d )", ErrCacheMiss, a.name, req.Query, req.Page)
+		}
+	}
+
+	used := &usedProxy{}
+
+	ctx = context.WithValue(ctx, engineKey{}, a.name)
+	ctx = context.WithValue(ctx, usedProxyKey{}, used)
+
+	resp, err := a.e.SearchContext(ctx, req)
+	if errors.Is(err, ErrBlocked) {
+		a.burn(used.get(), err)
+	}
+
+	if err == nil && a.cache != nil {
+		return a.remember(ctx, key, resp)
+	}
+
+	return resp, err
 }
 
 // ParseResponse parses returned response from .SearchContext(...) or .Search(...) methods
 func (a *API) ParseResponse(response *http.Response) ([]Result, error) {
-	return a.e.ParseResponse(response)
+	return a.ParseResponseContext(context.Background(), response)
+}
+
+// ParseResponseContext is like ParseResponse(...) but stops reading response when ctx is done.
+//
+// if session of engine has a proxy pool, the proxy which is blocked is burnt
+func (a *API) ParseResponseContext(ctx context.Context, response *http.Response) ([]Result, error) {
+	var proxy *url.URL
+	if response.Request != nil {
+		proxy = proxyOf(response.Request.Context())
+	}
+
+	results, err := a.e.ParseResponseContext(ctx, response)
+	if errors.Is(err, ErrBlocked) {
+		a.burn(proxy, err)
+	}
+
+	a.mu.Lock()
+	entry := a.pending[response]
+	delete(a.pending, response)
+	a.mu.Unlock()
+
+	if entry != nil && (err == nil || err == ErrNoMoreResults) {
+		if err := a.cache.Put(entry); err != nil {
+			a.logf("|  cache: %s\n\n", err.Error())
+		}
+	}
+
+	return results, err
+}
+
+// SetCache sets cache of responses; nil cache disables it
+func (a *API) SetCache(c *Cache) {
+	a.cache = c
+}
+
+// Cache returns cache of responses, or nil if not set
+func (a *API) Cache() *Cache {
+	return a.cache
+}
+
+// searchRequestKey is context key of search request of a cached response
+type searchRequestKey struct{}
+
+// CachedRequest returns search request of response, if it's returned from cache by
+// API.SearchContext(...); engines use it to restore their state of the page (e.g. next
+// page token) which is stored when response is fetched.
+//
+// returns nil if response is not returned from cache
+func CachedRequest(response *http.Response) *SearchRequest {
+	if response.Request == nil {
+		return nil
+	}
+
+	req, _ := response.Request.Context().Value(searchRequestKey{}).(*SearchRequest)
+	return req
+}
+
+// cacheKey returns cache key of req
+func (a *API) cacheKey(req *SearchRequest) string {
+	extra := ""
+	if k, ok := a.e.(CacheKeyer); ok {
+		extra = k.CacheKey()
+	}
+
+	return a.cache.Key(a.name, req, extra)
+}
+
+// remember reads body of resp to cache it when it's parsed, and returns resp
+// with the read body
+func (a *API) remember(ctx context.Context, key string, resp *http.Response) (*http.Response, error) {
+	body, err := ReadBody(ctx, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// body is decoded
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(body))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+
+	entry := &CacheEntry{
+		Key:        key,
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Time:       time.Now().UTC(),
+		Body:       body,
+	}
+
+	a.mu.Lock()
+	if a.pending == nil {
+		a.pending = make(map[*http.Response]*CacheEntry)
+	}
+	a.pending[resp] = entry
+	a.mu.Unlock()
+
+	return resp, nil
+}
+
+// logf prints a verbose message by session of engine
+func (a *API) logf(format string, args ...interface{}) {
+	if session := a.e.Session(); session != nil {
+		session.Logf(format, args...)
+	}
+}
+
+// burn burns proxy in proxy pool of session, because of err
+func (a *API) burn(proxy *url.URL, err error) {
+	session := a.e.Session()
+	if proxy == nil || session == nil {
+		return
+	}
+
+	if pool := session.ProxyPool(); pool != nil {
+		pool.Burn(proxy)
+		session.Logf("|  proxy burnt: %s ( %s )\n\n", proxy.Redacted(), err.Error())
+	}
 }
 
 // ParseHTML parses html responsed from google
@@ -100,8 +315,18 @@ func (a *API) Usage() {
 	a.e.Usage()
 }
 
+// SetSession sets session which engine sends requests with
+func (a *API) SetSession(s *Session) {
+	a.e.SetSession(s)
+}
+
+// Session returns session of engine
+func (a *API) Session() *Session {
+	return a.e.Session()
+}
+
 // String returns string ( format: "API( name version | description )" )
-func (a API) String() string {
+func (a *API) String() string {
 	return "API( " + a.name + " " + a.e.Version() + " | " + a.e.Description() + " )"
 }
 
@@ -122,7 +347,7 @@ func Use(engineName string) (*API, error) {
 		return nil, err
 	}
 
-	return &API{engineName, e}, nil
+	return &API{name: engineName, e: e}, nil
 }
 
 // UseWithoutStart(...) like Use(...) but not call Start()
@@ -134,5 +359,5 @@ func UseWithoutStart(engineName string) (*API, error) {
 		return nil, fmt.Errorf("dorkali: unknown engine %q (forgotten import?)", engineName)
 	}
 
-	return &API{engineName, f()}, nil
+	return &API{name: engineName, e: f()}, nil
 }
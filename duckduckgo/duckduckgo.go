@@ -0,0 +1,500 @@
+package duckduckgo
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/awolverp/dorkali"
+	"github.com/awolverp/dorkali/html"
+)
+
+var (
+	_ dorkali.Engine = (*DuckDuckGoEngine)(nil)
+	_ dorkali.Result = (*DuckDuckGoResult)(nil)
+)
+
+const (
+	Version = "v1.0.0"
+
+	// no-javascript version of duckduckgo
+	URL = "https://html.duckduckgo.com/html/"
+)
+
+func init() {
+	dorkali.RegisterEngine("duckduckgo", NewDuckDuckGoEngine)
+}
+
+type DuckDuckGoEngine struct {
+	Opt options
+
+	session *dorkali.Session
+
+	// "Next" forms of fetched pages; key is returned by page_key(...)
+	mu   sync.Mutex
+	next map[string]url.Values
+}
+
+func NewDuckDuckGoEngine() dorkali.Engine {
+	return &DuckDuckGoEngine{
+		session: dorkali.NewSession(),
+		next:    make(map[string]url.Values),
+	}
+}
+
+func (engine *DuckDuckGoEngine) Start() error {
+	return nil
+}
+
+func (engine *DuckDuckGoEngine) Version() string {
+	return Version
+}
+
+func (engine *DuckDuckGoEngine) Description() string {
+	return "Searches in duckduckgo search engine (html version)"
+}
+
+func (engine *DuckDuckGoEngine) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&engine.Opt.UserAgent, "U", engine.Opt.UserAgent, "") // user agent
+}
+
+func (engine *DuckDuckGoEngine) Usage() {
+	fmt.Print(flagUsageText)
+}
+
+func (engine *DuckDuckGoEngine) SetSession(s *dorkali.Session) {
+	engine.session = s
+}
+
+func (engine *DuckDuckGoEngine) Session() *dorkali.Session {
+	return engine.session
+}
+
+func (engine *DuckDuckGoEngine) Search(sr *dorkali.SearchRequest) (*http.Response, error) {
+	return engine.SearchContext(context.Background(), sr)
+}
+
+func (engine *DuckDuckGoEngine) SearchContext(ctx context.Context, sr *dorkali.SearchRequest) (*http.Response, error) {
+	if err := sr.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := engine.Start(); err != nil {
+		return nil, err
+	}
+
+	form := generate_form(sr)
+	key := page_key(form)
+
+	if sr.Page > 0 {
+		next, err := engine.next_page(ctx, sr, key)
+		if err != nil {
+			return nil, err
+		}
+
+		form = next
+	}
+
+	// remember page of response, to store its "Next" form in ParseResponseContext(...)
+	ctx = context.WithValue(ctx, pageKey{}, pageState{key, sr.Page})
+
+	body := form.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", URL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if engine.Opt.UserAgent != "" {
+		req.Header.Add("User-Agent", engine.Opt.UserAgent)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Origin", "https://html.duckduckgo.com")
+	req.Header.Add("Referer", "https://html.duckduckgo.com/")
+
+	// language of results; otherwise profile sends english
+	if sr.Language != "" {
+		req.Header.Add("Accept-Language", sr.Language)
+	}
+
+	for k, values := range sr.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+
+	for _, c := range sr.Cookies {
+		req.AddCookie(c)
+	}
+
+	engine.session.Logf("|  form: %s\n", body)
+
+	resp, err := engine.session.Do(req, sr.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dorkali.CheckStatus("duckduckgo", resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (engine *DuckDuckGoEngine) ParseResponse(response *http.Response) ([]dorkali.Result, error) {
+	return engine.ParseResponseContext(context.Background(), response)
+}
+
+func (engine *DuckDuckGoEngine) ParseResponseContext(ctx context.Context, response *http.Response) ([]dorkali.Result, error) {
+	b, err := dorkali.ReadBody(ctx, response)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := html.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	next := next_form(doc)
+
+	if response.Request != nil && next != nil {
+		state, ok := response.Request.Context().Value(pageKey{}).(pageState)
+		if sr := dorkali.CachedRequest(response); !ok && sr != nil {
+			// cached page; its "Next" form is not stored yet in this process
+			state, ok = pageState{page_key(generate_form(sr)), sr.Page}, true
+		}
+
+		if ok {
+			engine.mu.Lock()
+			engine.next[state.key+"\x00"+strconv.Itoa(state.page+1)] = next
+			engine.mu.Unlock()
+		}
+	}
+
+	return parse(doc, next != nil)
+}
+
+func (engine *DuckDuckGoEngine) ParseHTML(h string) ([]dorkali.Result, error) {
+	doc, err := html.Parse(strings.NewReader(h))
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(doc, next_form(doc) != nil)
+}
+
+// parse returns results of doc, and dorkali.ErrNoMoreResults if there's no next page.
+//
+// returns *dorkali.CaptchaError if doc is the anomaly (captcha) page
+func parse(doc *html.HTMLParser, hasNext bool) ([]dorkali.Result, error) {
+	if doc.Find(&html.Match{Attributes: map[string]string{"class": "anomaly-modal__modal"}}) != nil {
+		return nil, &dorkali.CaptchaError{Engine: "duckduckgo", URL: URL}
+	}
+
+	var res []dorkali.Result
+
+	doc.FindAllFunc(&html.Match{Name: "div", Attributes: map[string]string{"class": "result"}}, func(e *html.Element) {
+		// skip ads
+		if e.Find(&html.Match{Attributes: map[string]string{"class": "result--ad"}}) != nil {
+			return
+		}
+
+		res = append(res, &DuckDuckGoResult{e})
+	})
+
+	if len(res) == 0 || !hasNext {
+		return res, dorkali.ErrNoMoreResults
+	}
+
+	return res, nil
+}
+
+// next_form returns fields of "Next" form of the page, or nil if not found
+func next_form(doc *html.HTMLParser) url.Values {
+	for _, form := range doc.FindAll(&html.Match{Name: "form", Parent: &html.Match{Name: "div", Attributes: map[string]string{"class": "nav-link"}}}) {
+		if form.Find(&html.Match{Name: "input", Attributes: map[string]string{"type": "submit", "value": "Next"}}) == nil {
+			continue
+		}
+
+		values := url.Values{}
+
+		for _, input := range form.FindAll(&html.Match{Name: "input", Attributes: map[string]string{"type": "hidden"}}) {
+			if name := input.Attr("name"); name != "" {
+				values.Add(name, input.Attr("value"))
+			}
+		}
+
+		return values
+	}
+
+	return nil
+}
+
+// generate_form returns form fields of first page of sr
+func generate_form(sr *dorkali.SearchRequest) url.Values {
+	form := url.Values{}
+
+	query := strings.TrimSpace(sr.Query)
+
+	filetype := sr.Filetype
+	if filetype == "" {
+		filetype = sr.Ext
+	}
+
+	intext := sr.Intext
+	if intext != "" {
+		// duckduckgo has no intext operator; search it as exact phrase
+		intext = `"` + strings.Trim(intext, `"`) + `"`
+	}
+
+	for _, op := range []string{
+		dorkali.Operator("site", sr.Site),
+		dorkali.Operator("inurl", sr.Inurl),
+		dorkali.Operator("intitle", sr.Intitle),
+		intext,
+		dorkali.Operator("filetype", filetype),
+	} {
+		if op != "" {
+			query += " " + op
+		}
+	}
+
+	form.Set("q", strings.TrimSpace(query))
+	form.Set("b", "")
+
+	if kl := region(sr.Region, sr.Language); kl != "" {
+		form.Set("kl", kl)
+	}
+
+	if sr.SafeSearch {
+		form.Set("kp", "1")
+	} else {
+		form.Set("kp", "-2")
+	}
+
+	switch sr.TimeRange {
+	case dorkali.PastHour, dorkali.PastDay:
+		// duckduckgo has no past hour filter
+		form.Set("df", "d")
+	case dorkali.PastWeek, dorkali.PastMonth, dorkali.PastYear:
+		form.Set("df", string(sr.TimeRange))
+	}
+
+	return form
+}
+
+// regions are duckduckgo regions and their "kl" values; first one is default
+// language of region
+var regions = map[string][]string{
+	"ar": {"ar-es"},
+	"at": {"at-de"},
+	"au": {"au-en"},
+	"be": {"be-nl", "be-fr"},
+	"bg": {"bg-bg"},
+	"br": {"br-pt"},
+	"ca": {"ca-en", "ca-fr"},
+	"ch": {"ch-de", "ch-fr", "ch-it"},
+	"cl": {"cl-es"},
+	"cn": {"cn-zh"},
+	"co": {"co-es"},
+	"ct": {"ct-ca"},
+	"cz": {"cz-cs"},
+	"de": {"de-de"},
+	"dk": {"dk-da"},
+	"ee": {"ee-et"},
+	"es": {"es-es"},
+	"fi": {"fi-fi"},
+	"fr": {"fr-fr"},
+	"gr": {"gr-el"},
+	"hk": {"hk-tzh"},
+	"hr": {"hr-hr"},
+	"hu": {"hu-hu"},
+	"id": {"id-id", "id-en"},
+	"ie": {"ie-en"},
+	"il": {"il-he"},
+	"in": {"in-en"},
+	"it": {"it-it"},
+	"jp": {"jp-jp"},
+	"kr": {"kr-kr"},
+	"lt": {"lt-lt"},
+	"lv": {"lv-lv"},
+	"mx": {"mx-es"},
+	"my": {"my-ms", "my-en"},
+	"nl": {"nl-nl"},
+	"no": {"no-no"},
+	"nz": {"nz-en"},
+	"pe": {"pe-es"},
+	"ph": {"ph-en", "ph-tl"},
+	"pl": {"pl-pl"},
+	"pt": {"pt-pt"},
+	"ro": {"ro-ro"},
+	"ru": {"ru-ru"},
+	"se": {"se-sv"},
+	"sg": {"sg-en"},
+	"sk": {"sk-sk"},
+	"sl": {"sl-sl"},
+	"th": {"th-th"},
+	"tr": {"tr-tr"},
+	"tw": {"tw-tzh"},
+	"ua": {"ua-uk"},
+	"uk": {"uk-en"},
+	"us": {"us-en", "ue-es"},
+	"ve": {"ve-es"},
+	"vn": {"vn-vi"},
+	"xa": {"xa-ar", "xa-en"},
+	"xl": {"xl-es"},
+	"za": {"za-en"},
+}
+
+// region returns duckduckgo "kl" value (e.g. us-en, de-de, ...) of region r; lang
+// selects language of regions which have several ones (e.g. ca-fr), otherwise default
+// language of region is used. returns "wt-wt" (no region) for unknown regions
+func region(r, lang string) string {
+	if r == "" {
+		return ""
+	}
+
+	r = strings.ToLower(r)
+	lang = strings.ToLower(lang)
+
+	if r == "gb" {
+		r = "uk"
+	}
+
+	kls, ok := regions[r]
+	if !ok {
+		return "wt-wt"
+	}
+
+	for _, kl := range kls {
+		if strings.HasSuffix(kl, "-"+lang) {
+			return kl
+		}
+	}
+
+	return kls[0]
+}
+
+// next_page returns form of page sr.Page (> 0), which is "Next" form of previous page.
+//
+// duckduckgo decides size of its pages, so offset of a page is unknown until previous
+// page is fetched; if previous page is not fetched yet (e.g. by -page or on resume), it's
+// fetched first.
+func (engine *DuckDuckGoEngine) next_page(ctx context.Context, sr *dorkali.SearchRequest, key string) (url.Values, error) {
+	id := key + "\x00" + strconv.Itoa(sr.Page)
+
+	engine.mu.Lock()
+	next, ok := engine.next[id]
+	engine.mu.Unlock()
+
+	if ok {
+		return next, nil
+	}
+
+	engine.session.Logf("|  duckduckgo: fetching page %d to find offset of page %d\n", sr.Page-1, sr.Page)
+
+	prev := *sr
+	prev.Page--
+
+	resp, err := engine.SearchContext(ctx, &prev)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if _, err := engine.ParseResponseContext(ctx, resp); err != nil && err != dorkali.ErrNoMoreResults {
+		return nil, err
+	}
+
+	engine.mu.Lock()
+	next, ok = engine.next[id]
+	engine.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("duckduckgo: page %d is after the last page of results", sr.Page)
+	}
+
+	return next, nil
+}
+
+// page_key returns key of a search, independent of page
+func page_key(form url.Values) string {
+	return form.Get("q") + "\x00" + form.Get("kl") + "\x00" + form.Get("kp") + "\x00" + form.Get("df")
+}
+
+type pageKey struct{}
+
+type pageState struct {
+	key  string
+	page int
+}
+
+type DuckDuckGoResult struct {
+	Doc *html.Element
+}
+
+func (r *DuckDuckGoResult) Title() string {
+	title := r.Doc.Find(&html.Match{Name: "a", Attributes: map[string]string{"class": "result__a"}})
+	if title == nil {
+		return ""
+	}
+
+	return title.Text()
+}
+
+func (r *DuckDuckGoResult) Description() string {
+	d := r.Doc.Find(&html.Match{Attributes: map[string]string{"class": "result__snippet"}})
+	if d == nil {
+		return ""
+	}
+
+	return d.Text()
+}
+
+func (r *DuckDuckGoResult) Url() string {
+	h := r.Doc.Find(&html.Match{Name: "a", Attributes: map[string]string{"class": "result__a"}})
+	if h == nil {
+		return ""
+	}
+
+	return filter_url(h.Attr("href"))
+}
+
+func (r *DuckDuckGoResult) String() string {
+	return fmt.Sprintf("> %s\n%s\n%s\n", r.Url(), r.Title(), r.Description())
+}
+
+// filter_url unwraps duckduckgo redirect urls
+//
+//	//duckduckgo.com/l/?uddg=https%3A%2F%2Fexample.com%2F&rut=...
+//		-> https://example.com/
+func filter_url(u string) string {
+	if u == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+
+	if !strings.HasSuffix(parsed.Host, "duckduckgo.com") {
+		return u
+	}
+
+	if parsed.Path == "/l/" || parsed.Path == "/l" {
+		if target := parsed.Query().Get("uddg"); target != "" {
+			return target
+		}
+	}
+
+	// ads and internal links
+	return ""
+}
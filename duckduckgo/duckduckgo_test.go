@@ -0,0 +1,268 @@
+package duckduckgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awolverp/dorkali"
+	"github.com/awolverp/dorkali/html"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func parseFixture(t *testing.T, name string) *html.HTMLParser {
+	t.Helper()
+
+	doc, err := html.Parse(strings.NewReader(string(readFixture(t, name))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestParse(t *testing.T) {
+	doc := parseFixture(t, "results.html")
+
+	results, err := parse(doc, next_form(doc) != nil)
+	if err != nil {
+		t.Fatalf("parse() error = %v, want nil", err)
+	}
+
+	// ad is skipped
+	want := []struct {
+		title, url, description string
+	}{
+		{
+			"The Go Programming Language",
+			"https://go.dev/",
+			"Go is an open source programming language supported by Google.",
+		},
+		{
+			"Documentation - The Go Programming Language",
+			"https://go.dev/doc/?a=1&b=2",
+			"The Go programming language is an open source project.",
+		},
+	}
+
+	if len(results) != len(want) {
+		t.Fatalf("parse() returns %d results, want %d", len(results), len(want))
+	}
+
+	for i, w := range want {
+		r := results[i]
+
+		if got := r.Title(); got != w.title {
+			t.Errorf("results[%d].Title() = %q, want %q", i, got, w.title)
+		}
+
+		if got := r.Url(); got != w.url {
+			t.Errorf("results[%d].Url() = %q, want %q", i, got, w.url)
+		}
+
+		if got := r.Description(); got != w.description {
+			t.Errorf("results[%d].Description() = %q, want %q", i, got, w.description)
+		}
+	}
+}
+
+func TestParseLastPage(t *testing.T) {
+	doc := parseFixture(t, "last_page.html")
+
+	results, err := parse(doc, next_form(doc) != nil)
+	if err != dorkali.ErrNoMoreResults {
+		t.Errorf("parse() error = %v, want ErrNoMoreResults", err)
+	}
+
+	if len(results) != 1 {
+		t.Errorf("parse() returns %d results, want 1", len(results))
+	}
+}
+
+func TestParseAnomaly(t *testing.T) {
+	_, err := parse(parseFixture(t, "anomaly.html"), false)
+
+	var captcha *dorkali.CaptchaError
+	if !errors.As(err, &captcha) || !errors.Is(err, dorkali.ErrBlocked) {
+		t.Errorf("parse() error = %v, want *CaptchaError", err)
+	}
+}
+
+func TestNextForm(t *testing.T) {
+	next := next_form(parseFixture(t, "results.html"))
+	if next == nil {
+		t.Fatal("next_form() = nil")
+	}
+
+	for k, want := range map[string]string{"q": "golang", "s": "23", "dc": "24", "vqd": "4-1234567890", "kl": "us-en", "nextParams": ""} {
+		if got := next.Get(k); got != want {
+			t.Errorf("next_form() %s = %q, want %q", k, got, want)
+		}
+	}
+
+	// "Previous" form is not the next one
+	if next := next_form(parseFixture(t, "last_page.html")); next != nil {
+		t.Errorf("next_form() of last page = %v, want nil", next)
+	}
+}
+
+func TestFilterURL(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"https://example.com/page", "https://example.com/page"},
+		{"//duckduckgo.com/l/?uddg=https%3A%2F%2Fexample.com%2F&rut=8f3b", "https://example.com/"},
+		{"https://duckduckgo.com/l?uddg=https%3A%2F%2Fexample.com%2Fa%3Fb%3D1", "https://example.com/a?b=1"},
+		// ads and internal links
+		{"https://duckduckgo.com/y.js?ad_domain=example.com", ""},
+		{"https://duckduckgo.com/l/?rut=8f3b", ""},
+		{"https://html.duckduckgo.com/html/", ""},
+	} {
+		if got := filter_url(tt.in); got != tt.want {
+			t.Errorf("filter_url(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRegion(t *testing.T) {
+	for _, tt := range []struct {
+		region, lang, want string
+	}{
+		{"", "", ""},
+		{"", "en", ""},
+		{"us", "", "us-en"},
+		{"US", "", "us-en"},
+		{"us", "es", "ue-es"},
+		{"uk", "", "uk-en"},
+		{"gb", "", "uk-en"},
+		{"ca", "", "ca-en"},
+		{"ca", "fr", "ca-fr"},
+		{"br", "", "br-pt"},
+		{"se", "", "se-sv"},
+		{"de", "en", "de-de"},
+		{"ch", "it", "ch-it"},
+		{"zz", "", "wt-wt"},
+	} {
+		if got := region(tt.region, tt.lang); got != tt.want {
+			t.Errorf("region(%q, %q) = %q, want %q", tt.region, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestPageKey(t *testing.T) {
+	base := dorkali.SearchRequest{Query: "golang", Region: "us"}
+	key := page_key(generate_form(&base))
+
+	// key doesn't depend on page and request options
+	for _, sr := range []dorkali.SearchRequest{
+		{Query: "golang", Region: "us", Page: 3},
+		{Query: "golang", Region: "us", Count: 50, Timeout: time.Second},
+		{Query: " golang ", Region: "US", Language: "en"},
+	} {
+		if got := page_key(generate_form(&sr)); got != key {
+			t.Errorf("page_key(%+v) = %q, want %q", sr, got, key)
+		}
+	}
+
+	// but depends on search
+	for _, sr := range []dorkali.SearchRequest{
+		{Query: "golang tutorial", Region: "us"},
+		{Query: "golang", Region: "de"},
+		{Query: "golang", Region: "us", SafeSearch: true},
+		{Query: "golang", Region: "us", TimeRange: dorkali.PastWeek},
+		{Query: "golang", Region: "us", Site: "go.dev"},
+	} {
+		if got := page_key(generate_form(&sr)); got == key {
+			t.Errorf("page_key(%+v) = %q, want another key", sr, got)
+		}
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestNextPageAfterCacheHit(t *testing.T) {
+	api, err := dorkali.UseWithoutStart("duckduckgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := dorkali.NewCache(t.TempDir(), 0)
+	api.SetCache(cache)
+
+	session := dorkali.NewSession()
+	api.SetSession(session)
+
+	var forms []string
+
+	session.Transport().RegisterProtocol("https", roundTripper(func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		forms = append(forms, string(b))
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(string(readFixture(t, "last_page.html")))),
+			Request:    r,
+		}, nil
+	}))
+
+	sr := &dorkali.SearchRequest{Query: "golang", Region: "us"}
+
+	err = cache.Put(&dorkali.CacheEntry{
+		Key:        cache.Key("duckduckgo", sr, ""),
+		URL:        URL,
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Time:       time.Now(),
+		Body:       readFixture(t, "results.html"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	resp, err := api.SearchContext(ctx, sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.ParseResponseContext(ctx, resp); err != nil {
+		t.Fatalf("ParseResponseContext() error = %v, want nil", err)
+	}
+
+	if len(forms) != 0 {
+		t.Fatalf("first page is not returned from cache; sent %q", forms)
+	}
+
+	next := *sr
+	next.Page = 1
+
+	resp, err = api.SearchContext(ctx, &next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// "Next" form of cached page is sent, without fetching first page again
+	if len(forms) != 1 || !strings.Contains(forms[0], "s=23") || !strings.Contains(forms[0], "vqd=4-1234567890") {
+		t.Errorf("sent forms = %q, want \"Next\" form of cached page", forms)
+	}
+}
@@ -0,0 +1,15 @@
+package duckduckgo
+
+const flagUsageText = "*DuckDuckGo Options:\n" +
+	"\t-U User-Agent       Pass custom User-Agent header. (default from -profile)\n\n" +
+	"*Notes:\n" +
+	"\t-count is ignored; duckduckgo decides number of results per page.\n" +
+	"\t-region sets the region, in its default language (e.g. -region ca is ca-en); -lang\n" +
+	"\t selects another language of regions which have several. (e.g. -region ca -lang fr is ca-fr)\n" +
+	"\t unknown regions search in all regions (wt-wt).\n" +
+	"\t-intext is searched as exact phrase, and -ext as filetype:TEXT.\n"
+
+type options struct {
+	// (Request Options) Request User-Agent
+	UserAgent string
+}
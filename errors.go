@@ -0,0 +1,133 @@
+package dorkali
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrBlocked is matched (by errors.Is) by every error which means search engine blocked the request
+	ErrBlocked = errors.New("dorkali: blocked by search engine")
+
+	// ErrCaptcha is matched (by errors.Is) by *CaptchaError
+	ErrCaptcha = errors.New("dorkali: captcha required")
+
+	// ErrRateLimited is matched (by errors.Is) by *RateLimitError
+	ErrRateLimited = errors.New("dorkali: rate limited")
+
+	// ErrConsent is returned when search engine responds a consent page instead of results
+	ErrConsent = errors.New("dorkali: consent required")
+)
+
+// BlockedError is returned when search engine blocks the request
+type BlockedError struct {
+	// Engine name
+	Engine string
+
+	// StatusCode of response
+	StatusCode int
+
+	// Reason of error, e.g. "status code 403"
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	return e.Engine + " blocked. ( " + e.Reason + " )"
+}
+
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// CaptchaError is returned when search engine wants to solve a captcha
+type CaptchaError struct {
+	// Engine name
+	Engine string
+
+	// URL of challenge page
+	URL string
+}
+
+func (e *CaptchaError) Error() string {
+	if e.URL == "" {
+		return e.Engine + " wants captcha."
+	}
+	return e.Engine + " wants captcha. ( " + e.URL + " )"
+}
+
+func (e *CaptchaError) Is(target error) bool {
+	return target == ErrCaptcha || target == ErrBlocked
+}
+
+// RateLimitError is returned when search engine responds too many requests
+type RateLimitError struct {
+	// Engine name
+	Engine string
+
+	// StatusCode of response
+	StatusCode int
+
+	// RetryAfter is the duration from Retry-After header; zero if not specified
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s rate limited. ( status code %d, retry after %s )", e.Engine, e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s rate limited. ( status code %d )", e.Engine, e.StatusCode)
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited || target == ErrBlocked
+}
+
+// CheckStatus returns error of blocking status codes (403 and 429) of response,
+// and closes its body if returns error
+func CheckStatus(engine string, response *http.Response) error {
+	var err error
+
+	switch response.StatusCode {
+	case http.StatusForbidden:
+		err = &BlockedError{Engine: engine, StatusCode: response.StatusCode, Reason: "returns status code 403"}
+
+	case http.StatusTooManyRequests:
+		err = &RateLimitError{
+			Engine:     engine,
+			StatusCode: response.StatusCode,
+			RetryAfter: ParseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	if err != nil {
+		response.Body.Close()
+	}
+
+	return err
+}
+
+// ParseRetryAfter parses value of Retry-After header, which is seconds or
+// a http date; returns zero if invalid
+func ParseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
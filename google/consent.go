@@ -0,0 +1,139 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/awolverp/dorkali"
+	"github.com/awolverp/dorkali/html"
+)
+
+const (
+	// values of SOCS cookie which google sets after rejecting or accepting consent
+	socsReject = "CAI"
+	socsAccept = "CAESEwgDEgk0ODE3Nzk3MjQaAmVuIAEaBgiA_LyaBg"
+)
+
+// is_consent returns true if resp is redirected to google consent page
+func is_consent(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Request.URL.Host, "consent.google.")
+}
+
+// consent answers google consent page of resp (and closes its body) by
+// submitting its "reject all" or "accept all" form; if the form is not
+// found or submitting fails, it sets consent cookies instead.
+func (engine *GoogleEngine) consent(ctx context.Context, session *dorkali.Session, resp *http.Response, timeout time.Duration) error {
+	pageURL := resp.Request.URL
+
+	b, err := dorkali.ReadBody(ctx, resp)
+	if err != nil {
+		return err
+	}
+
+	doc, err := html.Parse(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	action, values := consent_form(doc, engine.Opt.Consent == "accept")
+	if action == "" {
+		engine.setConsentCookies(session)
+		return nil
+	}
+
+	target, err := pageURL.Parse(action)
+	if err != nil {
+		engine.setConsentCookies(session)
+		return nil
+	}
+
+	session.Logf("|  consent: %s ( POST %s )\n\n", engine.Opt.Consent, target.String())
+
+	req, err := http.NewRequestWithContext(ctx, "POST", target.String(), strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+
+	if engine.Opt.UserAgent != "" {
+		req.Header.Add("User-Agent", engine.Opt.UserAgent)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Referer", pageURL.String())
+
+	resp, err = session.Do(req, timeout)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		engine.setConsentCookies(session)
+		return nil
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 || is_consent(resp) {
+		engine.setConsentCookies(session)
+	}
+
+	return nil
+}
+
+// setConsentCookies sets SOCS cookie of engine tld in session
+func (engine *GoogleEngine) setConsentCookies(session *dorkali.Session) {
+	value := socsReject
+	if engine.Opt.Consent == "accept" {
+		value = socsAccept
+	}
+
+	u, _ := url.Parse(fmt.Sprintf("https://www.google%s/", engine.Opt.Tld))
+
+	session.Jar.SetCookies(u, []*http.Cookie{
+		{
+			Name:    "SOCS",
+			Value:   value,
+			Path:    "/",
+			Domain:  ".google" + engine.Opt.Tld,
+			Expires: time.Now().AddDate(1, 0, 0),
+			Secure:  true,
+		},
+	})
+}
+
+// consent_form returns action and fields of consent form of doc; the form
+// which has set_eom=true rejects, and set_eom=false accepts.
+//
+// returns empty action if not found
+func consent_form(doc *html.HTMLParser, accept bool) (string, url.Values) {
+	eom := "true"
+	if accept {
+		eom = "false"
+	}
+
+	for _, form := range doc.FindAll(&html.Match{Name: "form"}) {
+		action := form.Attr("action")
+		if !strings.Contains(action, "consent.google.") && !strings.HasPrefix(action, "/save") {
+			continue
+		}
+
+		if form.Find(&html.Match{Name: "input", Attributes: map[string]string{"name": "set_eom", "value": eom}}) == nil {
+			continue
+		}
+
+		values := url.Values{}
+
+		for _, input := range form.FindAll(&html.Match{Name: "input"}) {
+			if name := input.Attr("name"); name != "" {
+				values.Add(name, input.Attr("value"))
+			}
+		}
+
+		return action, values
+	}
+
+	return "", nil
+}
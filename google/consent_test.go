@@ -0,0 +1,172 @@
+package google
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awolverp/dorkali"
+	"github.com/awolverp/dorkali/html"
+)
+
+func TestConsentForm(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "consent.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := html.Parse(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		accept bool
+		eom    string
+		sc     string
+	}{
+		{false, "true", ""},
+		{true, "false", "true"},
+	} {
+		action, values := consent_form(doc, tt.accept)
+
+		if action != "https://consent.google.com/save" {
+			t.Errorf("accept=%v: action = %q, want https://consent.google.com/save", tt.accept, action)
+		}
+
+		if got := values.Get("set_eom"); got != tt.eom {
+			t.Errorf("accept=%v: set_eom = %q, want %q", tt.accept, got, tt.eom)
+		}
+
+		if got := values.Get("set_sc"); got != tt.sc {
+			t.Errorf("accept=%v: set_sc = %q, want %q", tt.accept, got, tt.sc)
+		}
+
+		if got := values.Get("continue"); got != "https://www.google.com/search?q=golang" {
+			t.Errorf("accept=%v: continue = %q", tt.accept, got)
+		}
+	}
+
+	// "More options" form, and pages without consent form
+	for _, h := range []string{
+		`<form action="https://consent.google.com/dl"><input name="continue" value="x"></form>`,
+		`<form action="https://consent.google.com/save"><input name="set_eom" value="yes"></form>`,
+		`<form action="https://example.com/save"><input name="set_eom" value="true"></form>`,
+	} {
+		doc, _ := html.Parse(strings.NewReader(h))
+
+		if action, _ := consent_form(doc, false); action != "" {
+			t.Errorf("consent_form(%s) action = %q, want empty", h, action)
+		}
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// consentPage returns response of consent page which its body is h
+func consentPage(h string) *http.Response {
+	u, _ := url.Parse("https://consent.google.com/ml?continue=https://www.google.com/search")
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(h)),
+		Request:    &http.Request{URL: u},
+	}
+}
+
+// socs returns SOCS cookie of google in session, or empty string
+func socs(session *dorkali.Session) string {
+	u, _ := url.Parse("https://www.google.com/")
+
+	for _, c := range session.Jar.Cookies(u) {
+		if c.Name == "SOCS" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func TestConsent(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "consent.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, answer := range []string{"reject", "accept"} {
+		engine := NewGoogleEngine().(*GoogleEngine)
+		engine.Opt.Consent = answer
+
+		session := dorkali.NewSession()
+
+		var posted url.Values
+
+		session.Transport().RegisterProtocol("https", roundTripper(func(r *http.Request) (*http.Response, error) {
+			if r.Method != "POST" {
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: r}, nil
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			posted, _ = url.ParseQuery(string(body))
+
+			// google redirects to "continue" url
+			header := http.Header{"Location": {posted.Get("continue")}}
+			return &http.Response{StatusCode: http.StatusFound, Header: header, Body: http.NoBody, Request: r}, nil
+		}))
+
+		if err := engine.consent(context.Background(), session, consentPage(string(b)), 0); err != nil {
+			t.Fatal(err)
+		}
+
+		eom := "true"
+		if answer == "accept" {
+			eom = "false"
+		}
+
+		if got := posted.Get("set_eom"); got != eom {
+			t.Errorf("%s: posted set_eom = %q, want %q", answer, got, eom)
+		}
+
+		// form is submitted, so SOCS is not set by fallback
+		if got := socs(session); got != "" {
+			t.Errorf("%s: SOCS = %q, want empty", answer, got)
+		}
+	}
+}
+
+func TestConsentFallback(t *testing.T) {
+	for _, tt := range []struct {
+		answer, want string
+	}{
+		{"reject", socsReject},
+		{"accept", socsAccept},
+	} {
+		engine := NewGoogleEngine().(*GoogleEngine)
+		engine.Opt.Consent = tt.answer
+
+		session := dorkali.NewSession()
+
+		session.Transport().RegisterProtocol("https", roundTripper(func(r *http.Request) (*http.Response, error) {
+			t.Errorf("%s: unexpected request %s", tt.answer, r.URL)
+			return nil, io.EOF
+		}))
+
+		// consent page of unknown markup
+		page := consentPage(`<html><body><form action="https://consent.google.com/save"><button>OK</button></form></body></html>`)
+
+		if err := engine.consent(context.Background(), session, page, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := socs(session); got != tt.want {
+			t.Errorf("%s: SOCS = %q, want %q", tt.answer, got, tt.want)
+		}
+	}
+}
@@ -2,15 +2,14 @@ package google
 
 import (
 	"bytes"
-	"compress/gzip"
+	"context"
 	"flag"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/awolverp/dorkali"
@@ -18,15 +17,14 @@ import (
 )
 
 var (
-	_ dorkali.Engine = (*GoogleEngine)(nil)
-	_ dorkali.Result = (*GoogleResult)(nil)
+	_ dorkali.Engine     = (*GoogleEngine)(nil)
+	_ dorkali.CacheKeyer = (*GoogleEngine)(nil)
+	_ dorkali.Result     = (*GoogleResult)(nil)
 )
 
 const (
 	Version = "v1.1.4"
 
-	stdUserAgent = "Mozilla/5.0 (Windows NT 10.0; rv:91.0) Gecko/20100101 Firefox/91.0"
-
 	URL = "https://www.google%s/search"
 )
 
@@ -36,45 +34,40 @@ func init() {
 
 type GoogleEngine struct {
 	Opt options
+
+	// session of engine; keeps cookies of google between searches
+	session *dorkali.Session
+
+	// homepage which cookies received from; empty if not received yet
+	mu       sync.Mutex
+	homepage string
 }
 
 func NewGoogleEngine() dorkali.Engine {
 	return &GoogleEngine{
 		Opt: options{
-			Cookies: &collector{Seperator: "="},
-			Header:  &collector{Seperator: ":"},
+			Tld:     ".com",
+			Consent: "reject",
 		},
+		session: dorkali.NewSession(),
 	}
 }
 
 func (engine *GoogleEngine) Start() error {
-	parser := flag.NewFlagSet("google", flag.ExitOnError)
-
-	parser.Usage = func() { fmt.Printf("Use '%s help google' to see help information.\n", os.Args[0]) }
-
-	parser.BoolVar(&engine.Opt.Verbose, "v", false, "")              // verbose
-	parser.Var(engine.Opt.Cookies, "C", "")                          // cookies
-	parser.Var(engine.Opt.Header, "H", "")                           // headers
-	parser.StringVar(&engine.Opt.UserAgent, "U", stdUserAgent, "")   // user agent
-	parser.DurationVar(&engine.Opt.Timeout, "t", time.Second*20, "") // timeout
-	parser.IntVar(&engine.Opt.Start, "start", 0, "")                 // start
-	parser.StringVar(&engine.Opt.Tld, "tld", ".com", "")             // tld
-	parser.StringVar(&engine.Opt.Lang, "lang", "", "")               // lang
-	parser.BoolVar(&engine.Opt.Safe, "safe", false, "")              // verbose
-	parser.IntVar(&engine.Opt.Num, "n", 10, "")                      // num
-	parser.StringVar(&engine.Opt.Country, "country", "", "")         // country
-	parser.StringVar(&engine.Opt.Inurl, "inurl", "", "")             // inurl
-	parser.StringVar(&engine.Opt.Intext, "intext", "", "")           // intext
-	parser.StringVar(&engine.Opt.Filetype, "filetype", "", "")       // filetype
-	parser.StringVar(&engine.Opt.Ext, "ext", "", "")                 // ext
-
-	parser.Parse(os.Args[2:])
-
-	engine.Opt.Query = parser.Arg(0)
+	if engine.Opt.Tld == "" {
+		engine.Opt.Tld = ".com"
+	} else if engine.Opt.Tld[0] != '.' {
+		engine.Opt.Tld = "." + engine.Opt.Tld
+	}
 
-	if engine.Opt.Query == "" {
-		return fmt.Errorf("error: query is required. use '%s help google' to see information", os.Args[0])
+	switch engine.Opt.Consent {
+	case "":
+		engine.Opt.Consent = "reject"
+	case "reject", "accept":
+	default:
+		return fmt.Errorf("google: invalid consent %q; use reject or accept", engine.Opt.Consent)
 	}
+
 	return nil
 }
 
@@ -86,110 +79,190 @@ func (engine *GoogleEngine) Description() string {
 	return "Searches in google search engine"
 }
 
+func (engine *GoogleEngine) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&engine.Opt.UserAgent, "U", engine.Opt.UserAgent, "")   // user agent
+	fs.StringVar(&engine.Opt.Tld, "tld", engine.Opt.Tld, "")             // tld
+	fs.StringVar(&engine.Opt.Consent, "consent", engine.Opt.Consent, "") // consent
+}
+
 func (engine *GoogleEngine) Usage() {
-	fmt.Printf(flagUsageText, os.Args[0])
+	fmt.Print(flagUsageText)
 }
 
-func (engine *GoogleEngine) Search(interface{}) (*http.Response, error) {
-	if engine.Opt.Tld == "" {
-		engine.Opt.Tld = ".com"
-	} else if engine.Opt.Tld[0] != '.' {
-		engine.Opt.Tld = "." + engine.Opt.Tld
+// CacheKey implements dorkali.CacheKeyer; responses of different tlds are cached separately
+func (engine *GoogleEngine) CacheKey() string {
+	return engine.Opt.Tld
+}
+
+func (engine *GoogleEngine) SetSession(s *dorkali.Session) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	engine.session = s
+	engine.homepage = ""
+}
+
+func (engine *GoogleEngine) Session() *dorkali.Session {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	return engine.session
+}
+
+func (engine *GoogleEngine) Search(sr *dorkali.SearchRequest) (*http.Response, error) {
+	return engine.SearchContext(context.Background(), sr)
+}
+
+func (engine *GoogleEngine) SearchContext(ctx context.Context, sr *dorkali.SearchRequest) (*http.Response, error) {
+	if err := sr.Validate(); err != nil {
+		return nil, err
 	}
 
-	cli := http.Client{Timeout: engine.Opt.Timeout}
+	if err := engine.Start(); err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://www.google%s/", engine.Opt.Tld), nil)
+	session := engine.Session()
+
+	// captcha page is not passed by retrying
+	ctx = dorkali.WithChallenge(ctx, is_sorry)
+
+	if len(sr.Cookies) == 0 {
+		engine.warmUp(ctx, session, sr.Timeout)
+	}
+
+	uri := generate_url(sr, engine.Opt.Tld)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("User-Agent", engine.Opt.UserAgent)
+	if engine.Opt.UserAgent != "" {
+		req.Header.Add("User-Agent", engine.Opt.UserAgent)
+	}
 
-	if len(engine.Opt.Cookies.Collected) == 0 {
-		resp, err := cli.Do(req)
-		if err == nil {
-			for _, c := range resp.Cookies() {
-				req.AddCookie(c)
-			}
-			req.Header.Add("Referer", resp.Request.URL.String())
+	if referer := engine.referer(); referer != "" {
+		req.Header.Add("Referer", referer)
+	}
+
+	// language of results; otherwise profile sends english
+	if sr.Language != "" {
+		req.Header.Add("Accept-Language", sr.Language)
+	}
+
+	for k, values := range sr.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
 		}
 	}
 
-	uri := generate_url(
-		engine.Opt.Query, engine.Opt.Tld, engine.Opt.Lang,
-		engine.Opt.Country, engine.Opt.Inurl, engine.Opt.Intext,
-		engine.Opt.Filetype, engine.Opt.Ext, engine.Opt.Num, engine.Opt.Start,
-		engine.Opt.Safe,
-	)
+	for _, c := range sr.Cookies {
+		req.AddCookie(c)
+	}
 
-	req.URL, _ = url.Parse(uri)
+	resp, err := session.Do(req, sr.Timeout)
+	if err != nil {
+		return nil, err
+	}
 
-	req.Header["DNT"] = []string{"1"}
-	req.Header.Add("Accept", "text/html")
-	req.Header.Add("Alt-Used", "www.google.com")
-	req.Header.Add("Host", fmt.Sprintf("www.google%s", engine.Opt.Tld))
+	if is_consent(resp) {
+		if err := engine.consent(ctx, session, resp, sr.Timeout); err != nil {
+			return nil, err
+		}
 
-	for _, v := range engine.Opt.Header.Collected {
-		if len(v) == 2 {
-			req.Header.Add(v[0], v[1])
+		// retry with consent cookies
+		resp, err = session.Do(req, sr.Timeout)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	for _, v := range engine.Opt.Cookies.Collected {
-		if len(v) == 2 {
-			req.AddCookie(&http.Cookie{Name: v[0], Value: v[1]})
-		}
+	if err := check_response(resp); err != nil {
+		return nil, err
 	}
 
-	if engine.Opt.Verbose {
+	return resp, nil
+}
 
-		print("|  " + uri + "\n\n")
+// check_response returns error if google redirected to sorry (captcha) or consent
+// page, or returned a blocking status code; and closes body if returns error
+func check_response(resp *http.Response) error {
+	u := resp.Request.URL
 
-		for k, values := range req.Header {
-			for _, v := range values {
-				println("|> " + k + ": " + v)
-			}
-		}
+	if is_sorry(resp) {
+		resp.Body.Close()
+		return &dorkali.CaptchaError{Engine: "google", URL: u.String()}
 	}
 
-	resp, err := cli.Do(req)
-
-	if err == nil && resp.StatusCode == 403 {
+	if is_consent(resp) {
 		resp.Body.Close()
-		return nil, fmt.Errorf("google blocked. ( returns status code 403 )")
+		return fmt.Errorf("google: %w ( %s )", dorkali.ErrConsent, u.String())
 	}
 
-	if engine.Opt.Verbose && err == nil {
+	return dorkali.CheckStatus("google", resp)
+}
 
-		println()
+// is_sorry returns true if resp is the sorry (captcha) page of google
+func is_sorry(resp *http.Response) bool {
+	return resp.Request != nil && strings.HasPrefix(resp.Request.URL.Path, "/sorry/")
+}
 
-		for k, values := range resp.Header {
-			for _, v := range values {
-				println("|< " + k + ": " + v)
-			}
-		}
+// warmUp requests to google homepage to receive cookies, once per tld; it's
+// skipped if session already has cookies of google (e.g. loaded from disk)
+func (engine *GoogleEngine) warmUp(ctx context.Context, session *dorkali.Session, timeout time.Duration) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
 
-		println()
+	homepage := fmt.Sprintf("https://www.google%s/", engine.Opt.Tld)
+	if engine.homepage == homepage {
+		return
 	}
 
-	return resp, err
-}
+	if u, err := url.Parse(homepage); err == nil && len(session.Jar.Cookies(u)) != 0 {
+		engine.homepage = homepage
+		return
+	}
 
-func (engine *GoogleEngine) ParseResponse(response *http.Response) ([]dorkali.Result, error) {
-	var (
-		b   []byte
-		err error
-	)
-
-	if response.Header.Get("Content-Encoding") == "gzip" {
-		b, err = gzipDecode(response.Body)
-		fmt.Println("gzip decoded")
+	req, err := http.NewRequestWithContext(ctx, "GET", homepage, nil)
+	if err != nil {
+		return
+	}
+
+	if engine.Opt.UserAgent != "" {
+		req.Header.Add("User-Agent", engine.Opt.UserAgent)
+	}
+
+	resp, err := session.Do(req, timeout)
+	if err != nil {
+		return
+	}
+
+	if is_consent(resp) {
+		if err := engine.consent(ctx, session, resp, timeout); err != nil {
+			return
+		}
 	} else {
-		b, err = io.ReadAll(response.Body)
+		resp.Body.Close()
 	}
 
-	response.Body.Close()
+	engine.homepage = homepage
+}
+
+// referer returns google homepage if cookies received from it
+func (engine *GoogleEngine) referer() string {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	return engine.homepage
+}
+
+func (engine *GoogleEngine) ParseResponse(response *http.Response) ([]dorkali.Result, error) {
+	return engine.ParseResponseContext(context.Background(), response)
+}
+
+func (engine *GoogleEngine) ParseResponseContext(ctx context.Context, response *http.Response) ([]dorkali.Result, error) {
+	b, err := dorkali.ReadBody(ctx, response)
 	if err != nil {
 		return nil, err
 	}
@@ -199,13 +272,12 @@ func (engine *GoogleEngine) ParseResponse(response *http.Response) ([]dorkali.Re
 		return nil, err
 	}
 
-	var res []dorkali.Result
-
-	doc.FindAllFunc(&html.Match{Name: "div", Attributes: map[string]string{"class": "g"}}, func(e *html.Element) {
-		res = append(res, &GoogleResult{e})
-	})
+	pageURL := ""
+	if response.Request != nil {
+		pageURL = response.Request.URL.String()
+	}
 
-	return res, nil
+	return parse(doc, pageURL)
 }
 
 func (engine *GoogleEngine) ParseHTML(h string) ([]dorkali.Result, error) {
@@ -214,53 +286,119 @@ func (engine *GoogleEngine) ParseHTML(h string) ([]dorkali.Result, error) {
 		return nil, err
 	}
 
+	return parse(doc, "")
+}
+
+// parse returns results of doc, and dorkali.ErrNoMoreResults if there's no result
+// or no next page.
+//
+// returns error if doc is a captcha or consent page, or has no result and
+// isn't a search page.
+func parse(doc *html.HTMLParser, pageURL string) ([]dorkali.Result, error) {
+	for _, m := range captchaMatches {
+		if doc.Find(m) != nil {
+			return nil, &dorkali.CaptchaError{Engine: "google", URL: pageURL}
+		}
+	}
+
+	if doc.Find(consentMatch) != nil {
+		return nil, fmt.Errorf("google: %w", dorkali.ErrConsent)
+	}
+
 	var res []dorkali.Result
 
 	doc.FindAllFunc(&html.Match{Name: "div", Attributes: map[string]string{"class": "g"}}, func(e *html.Element) {
 		res = append(res, &GoogleResult{e})
 	})
 
+	if len(res) == 0 {
+		for _, m := range searchPageMatches {
+			if doc.Find(m) != nil {
+				return nil, dorkali.ErrNoMoreResults
+			}
+		}
+
+		return nil, &dorkali.BlockedError{Engine: "google", StatusCode: 200, Reason: "no results and not a search page"}
+	}
+
+	if doc.Find(nextPageMatch) == nil {
+		return res, dorkali.ErrNoMoreResults
+	}
+
 	return res, nil
 }
 
-func generate_url(query, tld, lang, country, inurl, intext, filetype, ext string, num, start int, safe bool) string {
+var (
+	// elements of google captcha pages
+	captchaMatches = []*html.Match{
+		{Name: "form", Attributes: map[string]string{"id": "captcha-form"}},
+		{Attributes: map[string]string{"id": "recaptcha"}},
+		{Attributes: map[string]string{"class": "g-recaptcha"}},
+	}
+
+	// form of google consent page
+	consentMatch = &html.Match{Name: "form", Attributes: map[string]string{"action": "https://consent.google.com/save"}}
+
+	// "Next" link of google search pages
+	nextPageMatch = &html.Match{Name: "a", Attributes: map[string]string{"id": "pnnext"}}
+
+	// containers of google search pages; a page without results and without
+	// these containers is not a real search page
+	searchPageMatches = []*html.Match{
+		{Attributes: map[string]string{"id": "search"}},
+		{Attributes: map[string]string{"id": "rso"}},
+		{Attributes: map[string]string{"id": "res"}},
+		{Attributes: map[string]string{"id": "topstuff"}},
+		{Attributes: map[string]string{"id": "main"}},
+	}
+)
+
+func generate_url(sr *dorkali.SearchRequest, tld string) string {
 	u, _ := url.Parse(fmt.Sprintf(URL, tld))
 	q := u.Query()
 
-	if lang != "" {
-		q.Set("lr", "lang_"+url.QueryEscape(lang))
+	if sr.Language != "" {
+		q.Set("lr", "lang_"+sr.Language)
+		q.Set("hl", sr.Language)
 	}
 
-	if safe {
+	if sr.SafeSearch {
 		q.Set("safe", "on")
 	} else {
 		q.Set("safe", "off")
 	}
 
-	if country != "" {
-		q.Set("cr", url.QueryEscape(country))
+	if sr.Region != "" {
+		q.Set("cr", "country"+strings.ToUpper(sr.Region))
+		q.Set("gl", strings.ToLower(sr.Region))
+	}
+
+	if sr.TimeRange != dorkali.AnyTime {
+		q.Set("tbs", "qdr:"+string(sr.TimeRange))
 	}
 
-	if start != 0 {
+	if start := sr.Offset(); start != 0 {
 		q.Set("start", strconv.Itoa(start))
 	}
 
-	q.Set("num", strconv.Itoa(num+3))
+	q.Set("num", strconv.Itoa(sr.PerPage()))
 
-	if inurl != "" {
-		query += " inurl:" + inurl
-	}
-	if intext != "" {
-		query += " intext:" + intext
-	}
-	if filetype != "" {
-		query += " filetype:" + filetype
-	}
-	if ext != "" {
-		query += " ext:" + ext
+	query := strings.TrimSpace(sr.Query)
+
+	for _, op := range []string{
+		dorkali.Operator("site", sr.Site),
+		dorkali.Operator("inurl", sr.Inurl),
+		dorkali.Operator("intitle", sr.Intitle),
+		dorkali.Operator("intext", sr.Intext),
+		dorkali.Operator("filetype", sr.Filetype),
+		dorkali.Operator("ext", sr.Ext),
+	} {
+		if op != "" {
+			query += " " + op
+		}
 	}
 
-	q.Set("q", url.QueryEscape(query))
+	q.Set("q", strings.TrimSpace(query))
 
 	u.RawQuery = q.Encode()
 
@@ -323,18 +461,3 @@ func filter_url(u string) string {
 
 	return u
 }
-
-func gzipDecode(r io.Reader) ([]byte, error) {
-	decoder, err := gzip.NewReader(r)
-	if err != nil {
-		return nil, err
-	}
-	defer decoder.Close()
-
-	b, err := io.ReadAll(decoder)
-	if err != nil {
-		return nil, err
-	}
-
-	return b, nil
-}
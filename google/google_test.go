@@ -0,0 +1,169 @@
+package google
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awolverp/dorkali"
+	"github.com/awolverp/dorkali/html"
+)
+
+func parseFixture(t *testing.T, name string) ([]dorkali.Result, error) {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := html.Parse(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parse(doc, "https://www.google.com/search?q=golang")
+}
+
+func TestParse(t *testing.T) {
+	results, err := parseFixture(t, "results.html")
+	if err != nil {
+		t.Fatalf("parse() error = %v, want nil", err)
+	}
+
+	want := []struct {
+		title, url, description string
+	}{
+		{
+			"The Go Programming Language",
+			"https://go.dev/",
+			"Go is an open source programming language that makes it simple to build secure, scalable systems.",
+		},
+		{
+			"Go (programming language) - Wikipedia",
+			"https://en.wikipedia.org/wiki/Go_(programming_language)",
+			"Go is a statically typed, compiled high-level programming language.",
+		},
+	}
+
+	if len(results) != len(want) {
+		t.Fatalf("parse() returns %d results, want %d", len(results), len(want))
+	}
+
+	for i, w := range want {
+		r := results[i]
+
+		if got := r.Title(); got != w.title {
+			t.Errorf("results[%d].Title() = %q, want %q", i, got, w.title)
+		}
+
+		if got := r.Url(); got != w.url {
+			t.Errorf("results[%d].Url() = %q, want %q", i, got, w.url)
+		}
+
+		if got := r.Description(); got != w.description {
+			t.Errorf("results[%d].Description() = %q, want %q", i, got, w.description)
+		}
+	}
+}
+
+func TestParseLastPage(t *testing.T) {
+	// no "Next" link; paginator doesn't request an empty page after it
+	results, err := parseFixture(t, "last_page.html")
+	if err != dorkali.ErrNoMoreResults {
+		t.Errorf("parse() error = %v, want ErrNoMoreResults", err)
+	}
+
+	if len(results) != 1 {
+		t.Errorf("parse() returns %d results, want 1", len(results))
+	}
+}
+
+func TestParseBlocked(t *testing.T) {
+	var captcha *dorkali.CaptchaError
+	var blocked *dorkali.BlockedError
+
+	for _, tt := range []struct {
+		file string
+		is   error
+		as   interface{}
+	}{
+		{"sorry.html", dorkali.ErrCaptcha, &captcha},
+		{"consent.html", dorkali.ErrConsent, nil},
+		{"no_results.html", dorkali.ErrNoMoreResults, nil},
+		{"not_search.html", dorkali.ErrBlocked, &blocked},
+	} {
+		results, err := parseFixture(t, tt.file)
+
+		if len(results) != 0 {
+			t.Errorf("%s: parse() returns %d results, want 0", tt.file, len(results))
+		}
+
+		if !errors.Is(err, tt.is) {
+			t.Errorf("%s: parse() error = %v, want %v", tt.file, err, tt.is)
+		}
+
+		if tt.as != nil && !errors.As(err, tt.as) {
+			t.Errorf("%s: parse() error = %T, want %T", tt.file, err, tt.as)
+		}
+	}
+
+	if captcha == nil || captcha.URL != "https://www.google.com/search?q=golang" {
+		t.Errorf("CaptchaError = %+v, want URL of page", captcha)
+	}
+
+	// a page without results is the end of pagination, not a block
+	if _, err := parseFixture(t, "no_results.html"); errors.Is(err, dorkali.ErrBlocked) {
+		t.Errorf("no_results.html: parse() error = %v, which is ErrBlocked", err)
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	var captcha *dorkali.CaptchaError
+	var blocked *dorkali.BlockedError
+	var limited *dorkali.RateLimitError
+
+	for _, tt := range []struct {
+		url    string
+		status int
+		is     error
+		as     interface{}
+	}{
+		{"https://www.google.com/search?q=golang", http.StatusOK, nil, nil},
+		{"https://www.google.com/sorry/index?continue=https://www.google.com/search", http.StatusTooManyRequests, dorkali.ErrCaptcha, &captcha},
+		{"https://consent.google.com/ml?continue=https://www.google.com/search", http.StatusOK, dorkali.ErrConsent, nil},
+		{"https://www.google.com/search?q=golang", http.StatusForbidden, dorkali.ErrBlocked, &blocked},
+		{"https://www.google.com/search?q=golang", http.StatusTooManyRequests, dorkali.ErrRateLimited, &limited},
+	} {
+		u, _ := url.Parse(tt.url)
+
+		resp := &http.Response{
+			StatusCode: tt.status,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    &http.Request{URL: u},
+		}
+
+		err := check_response(resp)
+
+		if tt.is == nil {
+			if err != nil {
+				t.Errorf("check_response(%d %s) error = %v, want nil", tt.status, tt.url, err)
+			}
+			continue
+		}
+
+		if !errors.Is(err, tt.is) {
+			t.Errorf("check_response(%d %s) error = %v, want %v", tt.status, tt.url, err, tt.is)
+		}
+
+		if tt.as != nil && !errors.As(err, tt.as) {
+			t.Errorf("check_response(%d %s) error = %T, want %T", tt.status, tt.url, err, tt.as)
+		}
+	}
+}
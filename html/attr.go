@@ -0,0 +1,113 @@
+package html
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// AttrOp is comparison of attribute value by AttrMatch
+type AttrOp string
+
+const (
+	// AttrEquals: value is equal to Value
+	AttrEquals AttrOp = "="
+
+	// AttrPrefix: value starts with Value
+	AttrPrefix AttrOp = "^="
+
+	// AttrSuffix: value ends with Value
+	AttrSuffix AttrOp = "$="
+
+	// AttrContains: value contains Value
+	AttrContains AttrOp = "*="
+
+	// AttrToken: value is a whitespace-separated list which contains Value (like class)
+	AttrToken AttrOp = "~="
+
+	// AttrDashPrefix: value is Value, or starts with Value followed by '-' (like lang)
+	AttrDashPrefix AttrOp = "|="
+)
+
+// AttrMatch is a condition of an attribute of element
+//
+// Example:
+//
+//	// <a href="/url?q=..." data-ved="...">
+//	&html.Match{Name: "a", Attrs: []*html.AttrMatch{
+//		{Name: "href", Op: html.AttrPrefix, Value: "/url?q="},
+//		{Name: "data-ved"},
+//	}}
+type AttrMatch struct {
+	// Name of attribute
+	Name string
+
+	// Op compares value of attribute with Value; if it's empty, value is compared
+	// by AttrEquals, or only existence of attribute is checked if Value is empty too
+	Op    AttrOp
+	Value string
+
+	// Regexp (if not nil) must match value of attribute
+	Regexp *regexp.Regexp
+
+	// IgnoreCase compares value with Value case-insensitively
+	IgnoreCase bool
+
+	// Not negates condition; elements without attribute match it
+	Not bool
+}
+
+// MatchNode returns true if attribute of node passes condition
+func (a *AttrMatch) MatchNode(node *html.Node) bool {
+	attr := getAttr(node.Attr, a.Name)
+	return (attr != nil && a.matchValue(attr.Val)) != a.Not
+}
+
+// matchValue returns true if val passes condition
+func (a *AttrMatch) matchValue(val string) bool {
+	if a.Regexp != nil && !a.Regexp.MatchString(val) {
+		return false
+	}
+
+	op := a.Op
+	if op == "" {
+		if a.Value == "" {
+			return true
+		}
+		op = AttrEquals
+	}
+
+	value := a.Value
+
+	if a.IgnoreCase {
+		val, value = strings.ToLower(val), strings.ToLower(value)
+	}
+
+	switch op {
+	case AttrEquals:
+		return val == value
+	case AttrPrefix:
+		return strings.HasPrefix(val, value)
+	case AttrSuffix:
+		return strings.HasSuffix(val, value)
+	case AttrContains:
+		return strings.Contains(val, value)
+	case AttrToken:
+		return hasToken(val, value)
+	case AttrDashPrefix:
+		return val == value || strings.HasPrefix(val, value+"-")
+	}
+
+	return false
+}
+
+// hasToken returns true if whitespace-separated list s contains token
+func hasToken(s, token string) bool {
+	for _, t := range strings.Fields(s) {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package html
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMatchAttributes(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	for _, tt := range []struct {
+		name  string
+		match *Match
+		want  string
+	}{
+		// class is a list of class names, other attributes are compared exactly
+		{"value with spaces", &Match{Attributes: map[string]string{"title": "Second paragraph"}}, "p2"},
+		{"part of value", &Match{Attributes: map[string]string{"title": "Second"}}, ""},
+		{"class name", &Match{Attributes: map[string]string{"class": "main"}}, "main"},
+		{"exact lang", &Match{Attributes: map[string]string{"lang": "en"}}, "side"},
+		{"existence", &Match{Attributes: map[string]string{"href": ""}}, "a1 a2"},
+		{"all attributes", &Match{Attributes: map[string]string{"class": "intro", "id": "p1"}}, "p1"},
+	} {
+		if got := ids(doc.FindAll(tt.match)); got != tt.want {
+			t.Errorf("%s: FindAll() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAttrMatch(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	for _, tt := range []struct {
+		name string
+		tag  string
+		attr AttrMatch
+		want string
+	}{
+		// operators
+		{"existence", "", AttrMatch{Name: "data-ved"}, "a1"},
+		{"equals", "", AttrMatch{Name: "title", Value: "Second paragraph"}, "p2"},
+		{"prefix", "", AttrMatch{Name: "href", Op: AttrPrefix, Value: "/url?q="}, "a2"},
+		{"suffix", "", AttrMatch{Name: "href", Op: AttrSuffix, Value: "one"}, "a1"},
+		{"contains", "", AttrMatch{Name: "title", Op: AttrContains, Value: "d p"}, "p2"},
+		{"token", "", AttrMatch{Name: "class", Op: AttrToken, Value: "first"}, "p1"},
+		{"dash prefix", "", AttrMatch{Name: "lang", Op: AttrDashPrefix, Value: "en"}, "main side"},
+
+		// ignore case
+		{"equals case", "", AttrMatch{Name: "class", Value: "intro"}, ""},
+		{"equals ignore case", "", AttrMatch{Name: "class", Value: "intro", IgnoreCase: true}, "p2"},
+		{"token case", "", AttrMatch{Name: "class", Op: AttrToken, Value: "INTRO"}, ""},
+		{"token ignore case", "", AttrMatch{Name: "class", Op: AttrToken, Value: "INTRO", IgnoreCase: true}, "p1 p2"},
+		{"dash prefix ignore case", "", AttrMatch{Name: "lang", Op: AttrDashPrefix, Value: "EN-us", IgnoreCase: true}, "main"},
+
+		// not; elements without attribute match it
+		{"not existence", "p", AttrMatch{Name: "class", Not: true}, "p3"},
+		{"not value", "li", AttrMatch{Name: "class", Value: "odd", Not: true}, "li1 li3 li4"},
+		{"not missing", "li", AttrMatch{Name: "data-missing", Not: true}, "li1 li2 li3 li4"},
+		{"not missing with op", "a", AttrMatch{Name: "data-ved", Op: AttrPrefix, Value: "x", Not: true}, "a2"},
+
+		// regexp and op must both pass
+		{"regexp", "", AttrMatch{Name: "id", Regexp: regexp.MustCompile(`^li[13]$`)}, "li1 li3"},
+		{"regexp and prefix", "", AttrMatch{Name: "href", Op: AttrPrefix, Value: "https://", Regexp: regexp.MustCompile(`one$`)}, "a1"},
+		{"regexp but not prefix", "", AttrMatch{Name: "href", Op: AttrPrefix, Value: "/url", Regexp: regexp.MustCompile(`one$`)}, ""},
+		{"regexp on original value", "", AttrMatch{Name: "class", Op: AttrToken, Value: "intro", IgnoreCase: true, Regexp: regexp.MustCompile(`^I`)}, "p2"},
+		{"not regexp", "a", AttrMatch{Name: "href", Regexp: regexp.MustCompile(`^https`), Not: true}, "a2"},
+	} {
+		attr := tt.attr
+
+		if got := ids(doc.FindAll(&Match{Name: tt.tag, Attrs: []*AttrMatch{&attr}})); got != tt.want {
+			t.Errorf("%s: FindAll() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -14,11 +14,20 @@
 //  })
 //
 //  fmt.Println(element.Text())
+//
+//  // or by css selectors
+//  for _, title := range doc.FindAll(html.MustCompile("div.g a > h3")) {
+//  	fmt.Println(title.Text())
+//  }
+//
+//  // or by xpath
+//  hrefs := doc.SelectStrings(html.MustXPath(`//div[@class="g"]//a/@href`))
 package html
 
 import (
 	"bytes"
 	"io"
+	"regexp"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -67,6 +76,16 @@ func (p HTMLParser) FindAllFunc(selection *Match, f func(*Element)) {
 	}
 }
 
+// Select returns elements of xpath expression x; see XPath(...)
+func (p HTMLParser) Select(x *XPathExpr) []*Element {
+	return x.Select(p.root)
+}
+
+// SelectStrings returns string values of nodes of xpath expression x (e.g. "//a/@href")
+func (p HTMLParser) SelectStrings(x *XPathExpr) []string {
+	return x.Strings(p.root)
+}
+
 // HTML returns root as HTML string
 func (p HTMLParser) HTML() string {
 	buf := bytes.Buffer{}
@@ -113,6 +132,84 @@ func (elem Element) Attr(name string) string {
 	return ""
 }
 
+// Attrs returns attributes of element
+func (elem Element) Attrs() map[string]string {
+	attrs := make(map[string]string, len(elem.Node.Attr))
+	for _, a := range elem.Node.Attr {
+		attrs[a.Key] = a.Val
+	}
+	return attrs
+}
+
+// HasClass returns true if class attribute of element has name
+func (elem Element) HasClass(name string) bool {
+	return hasToken(elem.Attr("class"), name)
+}
+
+// Index returns position of element between its sibling elements, starts from zero
+func (elem Element) Index() int {
+	i := 0
+	for s := prevElement(elem.Node); s != nil; s = prevElement(s) {
+		i++
+	}
+	return i
+}
+
+// Parent returns parent element
+//
+// returns nil if element has no parent element (e.g. html element)
+func (elem *Element) Parent() *Element {
+	p := elem.Node.Parent
+	if p == nil || p.Type != html.ElementNode {
+		return nil
+	}
+	return &Element{p}
+}
+
+// Children returns child elements; text nodes and comments are skipped
+func (elem *Element) Children() []*Element {
+	var children []*Element
+	for c := elem.Node.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			children = append(children, &Element{c})
+		}
+	}
+	return children
+}
+
+// NextElementSibling returns next sibling element, or nil if not found
+func (elem *Element) NextElementSibling() *Element {
+	if s := nextElement(elem.Node); s != nil {
+		return &Element{s}
+	}
+	return nil
+}
+
+// PrevElementSibling returns previous sibling element, or nil if not found
+func (elem *Element) PrevElementSibling() *Element {
+	if s := prevElement(elem.Node); s != nil {
+		return &Element{s}
+	}
+	return nil
+}
+
+// Closest returns element itself or its nearest ancestor which matches selection
+//
+// returns nil if not found
+func (elem *Element) Closest(selection *Match) *Element {
+	if selection == nil {
+		return nil
+	}
+
+	for n := elem.Node; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		if selection.MatchNode(n) {
+			return &Element{n}
+		}
+	}
+
+	return nil
+}
+
 // Clear deletes the tag from the tree of a given HTML.
 func (elem *Element) Clear() {
 	elem.Node.Parent.RemoveChild(elem.Node)
@@ -145,6 +242,16 @@ func (elem *Element) FindAll(selection *Match) []*Element {
 	return selectAllNodes(elem.Node, selection)
 }
 
+// Select returns elements of xpath expression x, evaluated on elem; see XPath(...)
+func (elem *Element) Select(x *XPathExpr) []*Element {
+	return x.Select(elem.Node)
+}
+
+// SelectStrings returns string values of nodes of xpath expression x, evaluated on elem
+func (elem *Element) SelectStrings(x *XPathExpr) []string {
+	return x.Strings(elem.Node)
+}
+
 // HTML returns node as HTML string
 func (elem Element) HTML() string {
 	buf := bytes.Buffer{}
@@ -174,16 +281,71 @@ type Match struct {
 	//  	-> <element href="*" ...>
 	Attributes map[string]string
 
+	// Attrs are conditions of attributes; see AttrMatch
+	//
+	// Example:
+	//  []*html.AttrMatch{{Name: "href", Op: html.AttrPrefix, Value: "/url?q="}}
+	//  	-> <element href="/url?q=..." ...>
+	Attrs []*AttrMatch
+
 	// Parent of element
 	//  <parent>
 	//  	...
 	//  	<element>
 	Parent *Match
 
-	// First child of element
+	// First child of element; whitespace-only text nodes and comments are skipped
 	//  <element>
 	//  	<child>
 	FirstChild *Match
+
+	// Any child element of element
+	//  <element>
+	//  	...
+	//  	<child>
+	AnyChild *Match
+
+	// Ancestor of element, at any level
+	//  <ancestor>
+	//  	...
+	//  		<element>
+	Ancestor *Match
+
+	// Has is a descendant of element, at any level
+	//  <element>
+	//  	...
+	//  		<descendant>
+	Has *Match
+
+	// Previous and next sibling elements of element
+	//  <prev-sibling>
+	//  <element>
+	//  <next-sibling>
+	PrevSibling *Match
+	NextSibling *Match
+
+	// Not is a match which element must not match
+	Not *Match
+
+	// Or is matches which element must match at least one of them
+	Or []*Match
+
+	// And is matches which element must match all of them
+	And []*Match
+
+	// TextContains is a string which text of element must contain
+	TextContains string
+
+	// TextRegexp (if not nil) must match text of element
+	TextRegexp *regexp.Regexp
+
+	// Func (if not nil) reports whether element matches; it's called for elements
+	// which match other fields
+	Func func(node *html.Node) bool
+
+	// deep matches nested elements of matched elements too, but not the element
+	// which is searched in; it's set by Compile(...)
+	deep bool
 }
 
 // MatchNode returns true if selection want this node
@@ -206,26 +368,25 @@ func (s *Match) MatchNode(node *html.Node) bool {
 			}
 
 			if v != "" {
-				if attr.Val == "" || (k != "class" && v != attr.Val) {
-					return false
-				}
-
-				ok := false
-
-				for _, className := range strings.Split(attr.Val, " ") {
-					if v == className {
-						ok = true
-						break
+				// class is a list of class names, other attributes are compared exactly
+				if k == "class" {
+					if !hasToken(attr.Val, v) {
+						return false
 					}
-				}
-
-				if !ok {
+				} else if v != attr.Val {
 					return false
 				}
 			}
 		}
 	}
 
+	// check attribute conditions
+	for _, a := range s.Attrs {
+		if !a.MatchNode(node) {
+			return false
+		}
+	}
+
 	// check parent
 	if s.Parent != nil {
 		if node.Parent == nil {
@@ -239,15 +400,108 @@ func (s *Match) MatchNode(node *html.Node) bool {
 
 	// check first child
 	if s.FirstChild != nil {
-		if node.FirstChild == nil {
+		child := firstChild(node)
+		if child == nil || !s.FirstChild.MatchNode(child) {
+			return false
+		}
+	}
+
+	// check any child
+	if s.AnyChild != nil {
+		ok := false
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && s.AnyChild.MatchNode(child) {
+				ok = true
+				break
+			}
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	// check siblings
+	if s.PrevSibling != nil {
+		prev := prevElement(node)
+		if prev == nil || !s.PrevSibling.MatchNode(prev) {
+			return false
+		}
+	}
+
+	if s.NextSibling != nil {
+		next := nextElement(node)
+		if next == nil || !s.NextSibling.MatchNode(next) {
+			return false
+		}
+	}
+
+	// check composition
+	if s.Not != nil && s.Not.MatchNode(node) {
+		return false
+	}
+
+	for _, m := range s.And {
+		if !m.MatchNode(node) {
 			return false
 		}
+	}
+
+	if len(s.Or) != 0 {
+		ok := false
 
-		if !s.FirstChild.MatchNode(node.FirstChild) {
+		for _, m := range s.Or {
+			if m.MatchNode(node) {
+				ok = true
+				break
+			}
+		}
+
+		if !ok {
 			return false
 		}
 	}
 
+	// check text
+	if s.TextContains != "" || s.TextRegexp != nil {
+		text := Element{node}.Text()
+
+		if !strings.Contains(text, s.TextContains) {
+			return false
+		}
+
+		if s.TextRegexp != nil && !s.TextRegexp.MatchString(text) {
+			return false
+		}
+	}
+
+	// check ancestor
+	if s.Ancestor != nil {
+		ok := false
+
+		for p := node.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+			if s.Ancestor.MatchNode(p) {
+				ok = true
+				break
+			}
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	// check descendant
+	if s.Has != nil && !hasDescendant(node, s.Has) {
+		return false
+	}
+
+	// check func
+	if s.Func != nil && !s.Func(node) {
+		return false
+	}
+
 	return true
 }
 
@@ -258,7 +512,10 @@ func selectNode(root *html.Node, selection *Match) *Element {
 
 	var crawler func(*html.Node)
 	crawler = func(node *html.Node) {
-		if node.Type == html.ElementNode && selection.MatchNode(node) {
+		if wnode != nil {
+			return
+		}
+		if node.Type == html.ElementNode && !(selection.deep && node == root) && selection.MatchNode(node) {
 			wnode = &Element{node}
 			return
 		}
@@ -279,9 +536,11 @@ func selectAllNodes(root *html.Node, selection *Match) []*Element {
 
 	var crawler func(*html.Node)
 	crawler = func(node *html.Node) {
-		if node.Type == html.ElementNode && selection.MatchNode(node) {
+		if node.Type == html.ElementNode && !(selection.deep && node == root) && selection.MatchNode(node) {
 			wnodes = append(wnodes, &Element{node})
-			return
+			if !selection.deep {
+				return
+			}
 		}
 		for child := node.FirstChild; child != nil; child = child.NextSibling {
 			crawler(child)
@@ -293,6 +552,32 @@ func selectAllNodes(root *html.Node, selection *Match) []*Element {
 	return wnodes
 }
 
+// hasDescendant returns true if a descendant element of node matches m
+func hasDescendant(node *html.Node, m *Match) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && (m.MatchNode(child) || hasDescendant(child, m)) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstChild returns first child of node, skipping whitespace-only text nodes and comments
+func firstChild(node *html.Node) *html.Node {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.CommentNode:
+			continue
+		case html.TextNode:
+			if strings.TrimSpace(child.Data) == "" {
+				continue
+			}
+		}
+		return child
+	}
+	return nil
+}
+
 func getAttr(attr []html.Attribute, name string) *html.Attribute {
 	for _, a := range attr {
 		if a.Key == name {
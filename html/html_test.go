@@ -0,0 +1,273 @@
+package html
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestFind(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	// id returns id attribute of element, or "nil"
+	id := func(e *Element) string {
+		if e == nil {
+			return "nil"
+		}
+		return e.Attr("id")
+	}
+
+	for _, tt := range []struct {
+		name  string
+		match *Match
+		want  string
+	}{
+		// first of sibling matches is returned, not the last one
+		{"sibling p", &Match{Name: "p"}, "p1"},
+		{"sibling li", &Match{Name: "li"}, "li1"},
+		{"sibling li with parent", &Match{Name: "li", Parent: &Match{Name: "ul"}}, "li1"},
+
+		// first in document order
+		{"nested", &Match{Name: "a"}, "a1"},
+		{"attribute", &Match{Attributes: map[string]string{"lang": ""}}, "main"},
+
+		{"missing", &Match{Name: "table"}, "nil"},
+		{"nil", nil, "nil"},
+	} {
+		if got := id(doc.Find(tt.match)); got != tt.want {
+			t.Errorf("%s: Find() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// element searches in its descendants
+	list := doc.Find(&Match{Attributes: map[string]string{"id": "list"}})
+
+	if got := id(list.Find(&Match{Name: "li"})); got != "li1" {
+		t.Errorf("list.Find(li) = %s, want li1", got)
+	}
+
+	if got := id(list.Find(&Match{Name: "p"})); got != "nil" {
+		t.Errorf("list.Find(p) = %s, want nil", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	for _, tt := range []struct {
+		name  string
+		match *Match
+		want  string
+	}{
+		// tree
+		{"parent", &Match{Name: "a", Parent: &Match{Name: "p"}}, "a1"},
+		{"ancestor", &Match{Name: "a", Ancestor: &Match{Name: "li"}}, "a2"},
+		{"far ancestor", &Match{Name: "span", Ancestor: &Match{Attributes: map[string]string{"id": "main"}}}, "s1"},
+		{"has", &Match{Name: "li", Has: &Match{Name: "a"}}, "li3"},
+		{"has at any level", &Match{Name: "div", Has: &Match{Name: "span"}}, "main side"},
+		{"any child", &Match{Name: "div", AnyChild: &Match{Name: "span"}}, "side"},
+		{"any child element", &Match{Name: "li", AnyChild: &Match{}}, "li3 li4"},
+		{"first child", &Match{Name: "ul", FirstChild: &Match{Name: "li"}}, "list"},
+		{"first child is text", &Match{Name: "p", FirstChild: &Match{Name: "a"}}, ""},
+
+		// siblings; text nodes and comments are skipped
+		{"prev sibling", &Match{Name: "p", PrevSibling: &Match{Name: "p"}}, "p2 p3"},
+		{"next sibling", &Match{Name: "p", NextSibling: &Match{Name: "p"}}, "p1 p2"},
+		{"prev sibling with class", &Match{Name: "li", PrevSibling: &Match{Attributes: map[string]string{"class": "odd"}}}, "li3"},
+		{"no next sibling", &Match{Name: "li", NextSibling: &Match{}}, "li1 li2 li3"},
+
+		// composition
+		{"not", &Match{Name: "p", Not: &Match{Attributes: map[string]string{"class": ""}}}, "p3"},
+		{"or", &Match{Or: []*Match{{Name: "h1"}, {Name: "span"}}}, "h1 s1 s2"},
+		{"or with name", &Match{Name: "li", Or: []*Match{{Attributes: map[string]string{"class": "odd"}}, {Has: &Match{Name: "span"}}}}, "li2 li4"},
+		{"and", &Match{Name: "li", And: []*Match{{Has: &Match{Name: "a"}}, {TextContains: "3"}}}, "li3"},
+		{"and fails", &Match{Name: "li", And: []*Match{{Has: &Match{Name: "a"}}, {TextContains: "4"}}}, ""},
+		{"not in and", &Match{Name: "li", And: []*Match{{Not: &Match{Has: &Match{}}}, {Not: &Match{Attributes: map[string]string{"class": ""}}}}}, "li1"},
+
+		// text
+		{"text contains", &Match{Name: "li", TextContains: "two"}, "li3"},
+		{"text of descendants", &Match{Name: "p", TextContains: "link"}, "p1"},
+		{"text regexp", &Match{Name: "li", TextRegexp: regexp.MustCompile(`^\d$`)}, "li1 li2 li4"},
+		{"text contains and regexp", &Match{Name: "p", TextContains: "n", TextRegexp: regexp.MustCompile(`^O`)}, "p1"},
+		{"text contains but not regexp", &Match{Name: "p", TextContains: "n", TextRegexp: regexp.MustCompile(`^T`)}, ""},
+	} {
+		if got := ids(doc.FindAll(tt.match)); got != tt.want {
+			t.Errorf("%s: FindAll() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchFirstChild(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<div id="d1"><!-- comment -->
+		<b id="b1"></b></div>
+		<div id="d2">text <b id="b2"></b></div>
+		<div id="d3"> <!-- comment --> </div>
+		<div id="d4">
+			text</div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// whitespace-only text nodes and comments are skipped
+	if got := ids(doc.FindAll(&Match{Name: "div", FirstChild: &Match{Name: "b"}})); got != "d1" {
+		t.Errorf("FindAll(div with first child b) = %q, want d1", got)
+	}
+
+	// but other text nodes are not
+	text := &Match{Func: func(node *html.Node) bool { return node.Type == html.TextNode }}
+
+	if got := ids(doc.FindAll(&Match{Name: "div", FirstChild: text})); got != "d2 d4" {
+		t.Errorf("FindAll(div with first child text) = %q, want d2 d4", got)
+	}
+}
+
+func TestElementTree(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	byID := func(id string) *Element {
+		e := doc.Find(&Match{Attributes: map[string]string{"id": id}})
+		if e == nil {
+			t.Fatalf("element #%s not found", id)
+		}
+		return e
+	}
+
+	// id returns id attribute of element, or "nil"
+	id := func(e *Element) string {
+		if e == nil {
+			return "nil"
+		}
+		return e.Attr("id")
+	}
+
+	// parent
+	for _, tt := range []struct {
+		id, want string
+	}{
+		{"a1", "p1"},
+		{"body", "root"},
+		{"root", "nil"},
+	} {
+		if got := id(byID(tt.id).Parent()); got != tt.want {
+			t.Errorf("#%s.Parent() = %s, want %s", tt.id, got, tt.want)
+		}
+	}
+
+	// children; text nodes and comments are skipped
+	for _, tt := range []struct {
+		id, want string
+	}{
+		{"main", "h1 p1 p2 p3 list"},
+		{"p1", "a1"},
+		{"p3", ""},
+	} {
+		if got := ids(byID(tt.id).Children()); got != tt.want {
+			t.Errorf("#%s.Children() = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+
+	// siblings; text nodes and comments are skipped
+	for _, tt := range []struct {
+		id, prev, next string
+	}{
+		{"h1", "nil", "p1"},
+		{"p2", "p1", "p3"},
+		{"p3", "p2", "list"},
+		{"list", "p3", "nil"},
+		{"a1", "nil", "nil"},
+	} {
+		e := byID(tt.id)
+
+		if got := id(e.PrevElementSibling()); got != tt.prev {
+			t.Errorf("#%s.PrevElementSibling() = %s, want %s", tt.id, got, tt.prev)
+		}
+
+		if got := id(e.NextElementSibling()); got != tt.next {
+			t.Errorf("#%s.NextElementSibling() = %s, want %s", tt.id, got, tt.next)
+		}
+	}
+
+	// index; text nodes and comments are skipped
+	for _, tt := range []struct {
+		id   string
+		want int
+	}{
+		{"root", 0},
+		{"h1", 0},
+		{"p3", 3},
+		{"list", 4},
+		{"li4", 3},
+		{"side", 1},
+	} {
+		if got := byID(tt.id).Index(); got != tt.want {
+			t.Errorf("#%s.Index() = %d, want %d", tt.id, got, tt.want)
+		}
+	}
+
+	// closest
+	a2 := byID("a2")
+
+	for _, tt := range []struct {
+		name  string
+		match *Match
+		want  string
+	}{
+		{"itself", &Match{Name: "a"}, "a2"},
+		{"parent", &Match{Name: "li"}, "li3"},
+		{"ancestor", &Match{Name: "div"}, "main"},
+		{"html", &Match{Name: "html"}, "root"},
+		{"missing", &Match{Name: "p"}, "nil"},
+		{"nil", nil, "nil"},
+	} {
+		if got := id(a2.Closest(tt.match)); got != tt.want {
+			t.Errorf("%s: #a2.Closest() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestElementAttrs(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	p1 := doc.Find(&Match{Name: "p"})
+
+	for _, tt := range []struct {
+		name string
+		want bool
+	}{
+		{"intro", true},
+		{"first", true},
+		{"Intro", false},
+		{"intro first", false},
+		{"", false},
+	} {
+		if got := p1.HasClass(tt.name); got != tt.want {
+			t.Errorf("#p1.HasClass(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	a1 := doc.Find(&Match{Name: "a"})
+	want := map[string]string{"id": "a1", "href": "https://example.com/one", "data-ved": "x"}
+
+	got := a1.Attrs()
+	if len(got) != len(want) {
+		t.Errorf("#a1.Attrs() = %v, want %v", got, want)
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("#a1.Attrs()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+
+	// changing returned map doesn't change element
+	got["id"] = "x"
+	if a1.Attr("id") != "a1" {
+		t.Errorf("#a1.Attr(id) = %q after changing Attrs(), want a1", a1.Attr("id"))
+	}
+
+	if got := doc.Find(&Match{Name: "title"}).Attrs(); len(got) != 1 || got["id"] != "title" {
+		t.Errorf("#title.Attrs() = %v, want map[id:title]", got)
+	}
+}
@@ -0,0 +1,690 @@
+package html
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SelectorError is returned by Compile(...) when selector is invalid
+type SelectorError struct {
+	// Selector which is compiled
+	Selector string
+
+	// Offset of error in Selector
+	Offset int
+
+	// Msg describes error
+	Msg string
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("html: invalid selector %q at offset %d: %s", e.Selector, e.Offset, e.Msg)
+}
+
+// Compile compiles a css selector to a match, which is used by Find(...) and FindAll(...)
+// like querySelector of browsers: Find returns first matched element in document order,
+// and FindAll returns all matched elements, nested matches too.
+//
+// supported selectors:
+//
+//	*, tag, #id, .class
+//	[attr], [attr=value], [attr~=value], [attr^=value], [attr$=value], [attr*=value],
+//	[attr|=value], and [attr=value i] to compare case-insensitively
+//	:first-child, :last-child, :only-child, :nth-child(an+b), :nth-last-child(an+b),
+//	:first-of-type, :last-of-type, :only-of-type, :nth-of-type(an+b), :nth-last-of-type(an+b),
+//	:empty, :root, :not(selectors), :has(relative selectors)
+//	descendant (A B), child (A > B), next sibling (A + B) and subsequent sibling (A ~ B) combinators
+//	groups (A, B)
+//
+// Example:
+//
+//	m, err := html.Compile(`div.g > div > a[href^="http"] h3`)
+//	// handle error ...
+//
+//	for _, title := range doc.FindAll(m) {
+//		fmt.Println(title.Text())
+//	}
+func Compile(selector string) (*Match, error) {
+	p := &selectorParser{s: selector}
+
+	list, err := p.parseList(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.s[p.pos])
+	}
+
+	return &Match{Func: list.match, deep: true}, nil
+}
+
+// MustCompile is like Compile(...) but panics if selector is invalid
+func MustCompile(selector string) *Match {
+	m, err := Compile(selector)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// selectorList is a group of selectors; an element matches it if matches one of them
+type selectorList []*complexSelector
+
+func (l selectorList) match(n *html.Node) bool {
+	for _, c := range l {
+		if c.matchAt(len(c.parts)-1, n, nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// complexSelector is compound selectors joined by combinators; combs[i] is
+// combinator between parts[i] and parts[i+1].
+//
+// first part of relative selectors (of :has) is nil, which matches the scope element
+type complexSelector struct {
+	parts []*compoundSelector
+	combs []byte
+}
+
+// matchAt returns true if n matches parts[:i+1]
+func (c *complexSelector) matchAt(i int, n *html.Node, scope *html.Node) bool {
+	part := c.parts[i]
+	if part == nil {
+		return n == scope
+	}
+
+	if !part.match(n) {
+		return false
+	}
+
+	if i == 0 {
+		return true
+	}
+
+	switch c.combs[i-1] {
+	case '>':
+		p := n.Parent
+		return p != nil && p.Type == html.ElementNode && c.matchAt(i-1, p, scope)
+
+	case '+':
+		p := prevElement(n)
+		return p != nil && c.matchAt(i-1, p, scope)
+
+	case '~':
+		for p := prevElement(n); p != nil; p = prevElement(p) {
+			if c.matchAt(i-1, p, scope) {
+				return true
+			}
+		}
+		return false
+
+	default:
+		for p := n.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+			if c.matchAt(i-1, p, scope) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchRelative returns true if an element relative to scope matches c
+func (c *complexSelector) matchRelative(scope *html.Node) bool {
+	found := false
+
+	var crawler func(*html.Node)
+	crawler = func(node *html.Node) {
+		for child := node.FirstChild; child != nil && !found; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+
+			if c.matchAt(len(c.parts)-1, child, scope) {
+				found = true
+				return
+			}
+
+			crawler(child)
+		}
+	}
+
+	crawler(scope)
+
+	if !found && (c.combs[0] == '+' || c.combs[0] == '~') {
+		for s := nextElement(scope); s != nil && !found; s = nextElement(s) {
+			if c.matchAt(len(c.parts)-1, s, scope) {
+				return true
+			}
+
+			crawler(s)
+		}
+	}
+
+	return found
+}
+
+// compoundSelector is a tag name (empty for any tag) and conditions of an element
+type compoundSelector struct {
+	tag   string
+	tests []func(*html.Node) bool
+}
+
+func (c *compoundSelector) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	if c.tag != "" && c.tag != n.Data {
+		return false
+	}
+
+	for _, test := range c.tests {
+		if !test(n) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type selectorParser struct {
+	s   string
+	pos int
+}
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return &SelectorError{Selector: p.s, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *selectorParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *selectorParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// skipSpace skips whitespaces, and returns true if skipped any
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for !p.eof() && isSpace(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// parseList parses selectors separated by comma, until end or ')'
+func (p *selectorParser) parseList(relative bool) (selectorList, error) {
+	var list selectorList
+
+	for {
+		p.skipSpace()
+
+		c, err := p.parseComplex(relative)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, c)
+
+		p.skipSpace()
+
+		if p.eof() || p.peek() == ')' {
+			return list, nil
+		}
+
+		if p.peek() != ',' {
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+
+		p.pos++
+	}
+}
+
+func (p *selectorParser) parseComplex(relative bool) (*complexSelector, error) {
+	c := &complexSelector{}
+
+	if relative {
+		comb := byte(' ')
+		if ch := p.peek(); ch == '>' || ch == '+' || ch == '~' {
+			comb = ch
+			p.pos++
+			p.skipSpace()
+		}
+
+		c.parts = append(c.parts, nil)
+		c.combs = append(c.combs, comb)
+	}
+
+	for {
+		compound, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+
+		c.parts = append(c.parts, compound)
+
+		space := p.skipSpace()
+
+		if p.eof() || p.peek() == ',' || p.peek() == ')' {
+			return c, nil
+		}
+
+		comb := byte(' ')
+
+		if ch := p.peek(); ch == '>' || ch == '+' || ch == '~' {
+			comb = ch
+			p.pos++
+			p.skipSpace()
+		} else if !space {
+			return nil, p.errorf("unexpected %q", ch)
+		}
+
+		c.combs = append(c.combs, comb)
+	}
+}
+
+func (p *selectorParser) parseCompound() (*compoundSelector, error) {
+	c := &compoundSelector{}
+	start := p.pos
+
+	if p.peek() == '*' {
+		p.pos++
+	} else if isIdentChar(p.peek()) {
+		c.tag = strings.ToLower(p.parseIdent())
+	}
+
+	for !p.eof() {
+		var test func(*html.Node) bool
+		var err error
+
+		switch p.peek() {
+		case '#':
+			p.pos++
+			id := p.parseIdent()
+			if id == "" {
+				return nil, p.errorf("expected id after '#'")
+			}
+
+			test = attrTest("id", "=", id, false)
+
+		case '.':
+			p.pos++
+			class := p.parseIdent()
+			if class == "" {
+				return nil, p.errorf("expected class name after '.'")
+			}
+
+			test = attrTest("class", "~=", class, false)
+
+		case '[':
+			test, err = p.parseAttr()
+
+		case ':':
+			test, err = p.parsePseudo()
+
+		default:
+			if p.pos == start {
+				return nil, p.errorf("expected selector")
+			}
+			return c, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.tests = append(c.tests, test)
+	}
+
+	if p.pos == start {
+		return nil, p.errorf("expected selector")
+	}
+
+	return c, nil
+}
+
+// parseAttr parses [attr], [attr OP value] and [attr OP value i]
+func (p *selectorParser) parseAttr() (func(*html.Node) bool, error) {
+	p.pos++
+	p.skipSpace()
+
+	name := strings.ToLower(p.parseIdent())
+	if name == "" {
+		return nil, p.errorf("expected attribute name")
+	}
+
+	p.skipSpace()
+
+	if p.peek() == ']' {
+		p.pos++
+		return attrTest(name, "", "", false), nil
+	}
+
+	op := ""
+	if p.peek() == '=' {
+		op = "="
+	} else if strings.IndexByte("~^$*|", p.peek()) >= 0 && p.pos+1 < len(p.s) && p.s[p.pos+1] == '=' {
+		op = p.s[p.pos : p.pos+2]
+	} else {
+		return nil, p.errorf("expected attribute operator")
+	}
+
+	p.pos += len(op)
+	p.skipSpace()
+
+	var value string
+
+	if ch := p.peek(); ch == '"' || ch == '\'' {
+		var err error
+		if value, err = p.parseString(); err != nil {
+			return nil, err
+		}
+	} else {
+		value = p.parseIdent()
+		if value == "" {
+			return nil, p.errorf("expected attribute value")
+		}
+	}
+
+	p.skipSpace()
+
+	fold := false
+
+	if ch := p.peek(); ch == 'i' || ch == 'I' || ch == 's' || ch == 'S' {
+		fold = ch == 'i' || ch == 'I'
+		p.pos++
+		p.skipSpace()
+	}
+
+	if p.peek() != ']' {
+		return nil, p.errorf("expected ']'")
+	}
+
+	p.pos++
+
+	return attrTest(name, op, value, fold), nil
+}
+
+// parsePseudo parses a pseudo-class
+func (p *selectorParser) parsePseudo() (func(*html.Node) bool, error) {
+	p.pos++
+
+	if p.peek() == ':' {
+		return nil, p.errorf("pseudo-elements are not supported")
+	}
+
+	start := p.pos
+	name := strings.ToLower(p.parseIdent())
+
+	switch name {
+	case "first-child":
+		return nthTest(0, 1, false, false), nil
+	case "last-child":
+		return nthTest(0, 1, true, false), nil
+	case "only-child":
+		first, last := nthTest(0, 1, false, false), nthTest(0, 1, true, false)
+		return func(n *html.Node) bool { return first(n) && last(n) }, nil
+	case "first-of-type":
+		return nthTest(0, 1, false, true), nil
+	case "last-of-type":
+		return nthTest(0, 1, true, true), nil
+	case "only-of-type":
+		first, last := nthTest(0, 1, false, true), nthTest(0, 1, true, true)
+		return func(n *html.Node) bool { return first(n) && last(n) }, nil
+	case "empty":
+		return isEmpty, nil
+	case "root":
+		return func(n *html.Node) bool {
+			return n.Parent != nil && n.Parent.Type == html.DocumentNode
+		}, nil
+	case "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type", "not", "has":
+	default:
+		p.pos = start
+		return nil, p.errorf("unknown pseudo-class :%s", name)
+	}
+
+	if p.peek() != '(' {
+		return nil, p.errorf("expected '(' after :%s", name)
+	}
+
+	p.pos++
+	p.skipSpace()
+
+	var test func(*html.Node) bool
+
+	switch name {
+	case "not":
+		list, err := p.parseList(false)
+		if err != nil {
+			return nil, err
+		}
+
+		test = func(n *html.Node) bool { return !list.match(n) }
+
+	case "has":
+		list, err := p.parseList(true)
+		if err != nil {
+			return nil, err
+		}
+
+		test = func(n *html.Node) bool {
+			for _, c := range list {
+				if c.matchRelative(n) {
+					return true
+				}
+			}
+			return false
+		}
+
+	default:
+		end := strings.IndexByte(p.s[p.pos:], ')')
+		if end < 0 {
+			return nil, p.errorf("expected ')'")
+		}
+
+		a, b, ok := parseNth(p.s[p.pos : p.pos+end])
+		if !ok {
+			return nil, p.errorf("invalid argument of :%s; use an+b, odd or even", name)
+		}
+
+		p.pos += end
+		test = nthTest(a, b, strings.Contains(name, "last"), strings.HasSuffix(name, "of-type"))
+	}
+
+	p.skipSpace()
+
+	if p.peek() != ')' {
+		return nil, p.errorf("expected ')'")
+	}
+
+	p.pos++
+
+	return test, nil
+}
+
+// parseIdent parses an identifier; a backslash escapes next character
+func (p *selectorParser) parseIdent() string {
+	var b strings.Builder
+
+	for !p.eof() {
+		ch := p.s[p.pos]
+
+		if ch == '\\' && p.pos+1 < len(p.s) {
+			b.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+			continue
+		}
+
+		if !isIdentChar(ch) {
+			break
+		}
+
+		b.WriteByte(ch)
+		p.pos++
+	}
+
+	return b.String()
+}
+
+// parseString parses a quoted string
+func (p *selectorParser) parseString() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+
+	var b strings.Builder
+
+	for !p.eof() {
+		ch := p.s[p.pos]
+
+		switch {
+		case ch == quote:
+			p.pos++
+			return b.String(), nil
+
+		case ch == '\\' && p.pos+1 < len(p.s):
+			b.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+
+		default:
+			b.WriteByte(ch)
+			p.pos++
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+// parseNth parses an+b, odd or even
+func parseNth(s string) (a, b int, ok bool) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), ""))
+
+	switch s {
+	case "odd":
+		return 2, 1, true
+	case "even":
+		return 2, 0, true
+	case "":
+		return 0, 0, false
+	}
+
+	i := strings.IndexByte(s, 'n')
+	if i < 0 {
+		b, err := strconv.Atoi(s)
+		return 0, b, err == nil
+	}
+
+	switch s[:i] {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, 0, false
+		}
+		a = n
+	}
+
+	if rest := s[i+1:]; rest != "" {
+		if rest[0] != '+' && rest[0] != '-' {
+			return 0, 0, false
+		}
+
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			return 0, 0, false
+		}
+		b = n
+	}
+
+	return a, b, true
+}
+
+// attrTest returns test of attribute name by op ("" checks only existence)
+func attrTest(name, op, value string, fold bool) func(*html.Node) bool {
+	// like browsers, empty value of these operators matches nothing
+	if value == "" && (op == "^=" || op == "$=" || op == "*=" || op == "~=") {
+		return func(n *html.Node) bool { return false }
+	}
+
+	a := &AttrMatch{Name: name, Op: AttrOp(op), Value: value, IgnoreCase: fold}
+	return a.MatchNode
+}
+
+// nthTest returns test of an+b position of element between its siblings (of
+// same type if ofType), counting from last if last
+func nthTest(a, b int, last, ofType bool) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		if n.Parent == nil {
+			return false
+		}
+
+		pos := 1
+
+		next := prevElement
+		if last {
+			next = nextElement
+		}
+
+		for s := next(n); s != nil; s = next(s) {
+			if !ofType || s.Data == n.Data {
+				pos++
+			}
+		}
+
+		if a == 0 {
+			return pos == b
+		}
+
+		// pos = a*k + b for a k >= 0
+		return (pos-b)%a == 0 && (pos-b)/a >= 0
+	}
+}
+
+// isEmpty returns true if n has no element and text child
+func isEmpty(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode || (c.Type == html.TextNode && c.Data != "") {
+			return false
+		}
+	}
+	return true
+}
+
+func prevElement(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+func nextElement(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f'
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '-' || ch == '_' || ch >= 0x80 ||
+		(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
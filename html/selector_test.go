@@ -0,0 +1,213 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDocument = `<html id="root"><head><title id="title">Test</title></head><body id="body">
+<div id="main" class="box main" lang="en-US">
+	<h1 id="h1">Header</h1>
+	<p id="p1" class="intro first">One <a id="a1" href="https://example.com/one" data-ved="x">link</a></p>
+	<p id="p2" class="Intro" title="Second paragraph">Two</p>
+	<!-- comment -->
+	<p id="p3"></p>
+	<ul id="list">
+		<li id="li1">1</li>
+		<li id="li2" class="odd">2</li>
+		<li id="li3">3 <a id="a2" href="/url?q=two">two</a></li>
+		<li id="li4"><span id="s1">4</span></li>
+	</ul>
+</div>
+<div id="side" lang="en"><span id="s2">side</span></div>
+</body></html>`
+
+func parseTestDocument(t *testing.T) *HTMLParser {
+	t.Helper()
+
+	doc, err := Parse(strings.NewReader(testDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+// ids returns id attributes of elements, separated by space
+func ids(elems []*Element) string {
+	s := make([]string, len(elems))
+	for i, e := range elems {
+		s[i] = e.Attr("id")
+	}
+	return strings.Join(s, " ")
+}
+
+func TestCompile(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	for _, tt := range []struct {
+		selector, want string
+	}{
+		// simple selectors
+		{"p", "p1 p2 p3"},
+		{"P", "p1 p2 p3"},
+		{"#li2", "li2"},
+		{".intro", "p1"},
+		{"p.intro.first", "p1"},
+		{"*#s1", "s1"},
+		{"#missing", ""},
+
+		// combinators
+		{"div p", "p1 p2 p3"},
+		{"body > div", "main side"},
+		{"#main > a", ""},
+		{"#main a", "a1 a2"},
+		{"h1 + p", "p1"},
+		{"#p1 ~ p", "p2 p3"},
+		{"#p2 + ul", ""},
+		{"#p3 + ul li:first-child", "li1"},
+		{"div > ul > li > span", "s1"},
+
+		// groups are in document order
+		{"span, h1", "h1 s1 s2"},
+		{"#li3 , #li1", "li1 li3"},
+
+		// structural pseudo-classes
+		{"li:first-child", "li1"},
+		{"li:last-child", "li4"},
+		{"span:only-child", "s1 s2"},
+		{"li:nth-child(2)", "li2"},
+		{"li:nth-child(odd)", "li1 li3"},
+		{"li:nth-child(even)", "li2 li4"},
+		{"li:nth-child(2n+1)", "li1 li3"},
+		{"li:nth-child(-n+2)", "li1 li2"},
+		{"li:nth-child( n + 3 )", "li3 li4"},
+		{"li:nth-last-child(1)", "li4"},
+		{"p:nth-of-type(2)", "p2"},
+		{"p:first-of-type", "p1"},
+		{"p:last-of-type", "p3"},
+		{"#main > :nth-last-of-type(1)", "h1 p3 list"},
+		{"h1:only-of-type", "h1"},
+		{"p:empty", "p3"},
+		{":root", "root"},
+		{"body:root", ""},
+
+		// :not and :has
+		{"p:not(.intro)", "p2 p3"},
+		{"li:not(:first-child, .odd)", "li3 li4"},
+		{"div:has(span)", "main side"},
+		{"div:has(> span)", "side"},
+		{"li:has(a, span)", "li3 li4"},
+		{"p:has(+ p)", "p1 p2"},
+		{"h1:has(~ ul)", "h1"},
+		{"li:not(:has(*))", "li1 li2"},
+
+		// attribute operators
+		{"[href]", "a1 a2"},
+		{"a[href=\"/url?q=two\"]", "a2"},
+		{"a[href='/url?q=two']", "a2"},
+		{"a[href^=https]", "a1"},
+		{"a[href$=two]", "a2"},
+		{"a[href*=example]", "a1"},
+		{"[class~=first]", "p1"},
+		{"[class~=fir]", ""},
+		{"[lang|=en]", "main side"},
+		{"[lang|=en-US]", "main"},
+		{"[class=intro i]", "p2"},
+		{"[class=intro]", ""},
+		{"[class=intro s]", ""},
+		{"[href^='']", ""},
+		{"[ data-ved = x ]", "a1"},
+	} {
+		m, err := Compile(tt.selector)
+		if err != nil {
+			t.Errorf("Compile(%q) error = %v", tt.selector, err)
+			continue
+		}
+
+		if got := ids(doc.FindAll(m)); got != tt.want {
+			t.Errorf("FindAll(%q) = %q, want %q", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestCompileFind(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	if got := doc.Find(MustCompile("li, a")).Attr("id"); got != "a1" {
+		t.Errorf("Find(li, a) = %q, want a1", got)
+	}
+
+	// selector is matched against the whole document, but only descendants are returned
+	list := doc.Find(MustCompile("#list"))
+
+	if got := ids(list.FindAll(MustCompile("div li > a"))); got != "a2" {
+		t.Errorf("FindAll(div li > a) = %q, want a2", got)
+	}
+
+	if got := ids(list.FindAll(MustCompile("ul"))); got != "" {
+		t.Errorf("FindAll(ul) = %q, want empty", got)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, tt := range []struct {
+		selector string
+		offset   int
+		msg      string
+	}{
+		{"", 0, "expected selector"},
+		{"div >", 5, "expected selector"},
+		{"a,,b", 2, "expected selector"},
+		{"div)", 3, "unexpected ')'"},
+		{"#", 1, "expected id after '#'"},
+		{"p.", 2, "expected class name after '.'"},
+		{"a[href", 6, "expected attribute operator"},
+		{"a[]", 2, "expected attribute name"},
+		{"a[href=]", 7, "expected attribute value"},
+		{"a[href=x y]", 9, "expected ']'"},
+		{`a[x="y]`, 7, "unterminated string"},
+		{"::before", 1, "pseudo-elements are not supported"},
+		{":foo", 1, "unknown pseudo-class :foo"},
+		{":not", 4, "expected '(' after :not"},
+		{":not(a", 6, "expected ')'"},
+		{"li:nth-child(x)", 13, "invalid argument of :nth-child; use an+b, odd or even"},
+		{"li:nth-child(2", 13, "expected ')'"},
+	} {
+		_, err := Compile(tt.selector)
+
+		e, ok := err.(*SelectorError)
+		if !ok {
+			t.Errorf("Compile(%q) error = %v, want *SelectorError", tt.selector, err)
+			continue
+		}
+
+		if e.Offset != tt.offset || e.Msg != tt.msg {
+			t.Errorf("Compile(%q) error at %d %q, want at %d %q", tt.selector, e.Offset, e.Msg, tt.offset, tt.msg)
+		}
+	}
+}
+
+func TestParseNth(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		a, b int
+		ok   bool
+	}{
+		{"odd", 2, 1, true},
+		{"EVEN", 2, 0, true},
+		{"3", 0, 3, true},
+		{"n", 1, 0, true},
+		{"-n+3", -1, 3, true},
+		{"+2n-1", 2, -1, true},
+		{"2n + 1", 2, 1, true},
+		{"", 0, 0, false},
+		{"2n1", 0, 0, false},
+		{"xn", 0, 0, false},
+	} {
+		a, b, ok := parseNth(tt.s)
+		if a != tt.a || b != tt.b || ok != tt.ok {
+			t.Errorf("parseNth(%q) = %d, %d, %v, want %d, %d, %v", tt.s, a, b, ok, tt.a, tt.b, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,1130 @@
+package html
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// XPathError is returned by XPath(...) when expression is invalid
+type XPathError struct {
+	// Expr which is compiled
+	Expr string
+
+	// Offset of error in Expr
+	Offset int
+
+	// Msg describes error
+	Msg string
+}
+
+func (e *XPathError) Error() string {
+	return fmt.Sprintf("html: invalid xpath %q at offset %d: %s", e.Expr, e.Offset, e.Msg)
+}
+
+// XPathExpr is a compiled xpath expression
+type XPathExpr struct {
+	expr string
+	root xpathNode
+}
+
+// XPath compiles an xpath 1.0 expression; a subset of xpath is supported:
+//
+//	location paths: /, //, ., .., *, @attr, @*, text(), node(), comment()
+//	axes: child, descendant, descendant-or-self, self, parent, ancestor, ancestor-or-self,
+//	following-sibling, preceding-sibling and attribute
+//	predicates: [1], [last()], [position() < 3], [@class], [@id="x"], [a/b], ...
+//	operators: or, and, =, !=, <, <=, >, >=, +, - and | (union)
+//	functions: last(), position(), count(), string(), normalize-space(), contains(),
+//	starts-with(), concat(), string-length(), name(), local-name(), not(), true(), false()
+//
+// Example:
+//
+//	x, err := html.XPath(`//div[contains(@class, "g")]//a[starts-with(@href, "http")]`)
+//	// handle error ...
+//
+//	for _, a := range doc.Select(x) {
+//		fmt.Println(a.Attr("href"))
+//	}
+func XPath(expr string) (*XPathExpr, error) {
+	p := &xpathParser{expr: expr}
+
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != 0 {
+		return nil, p.errorf(t.pos, "unexpected %q", t.s)
+	}
+
+	return &XPathExpr{expr: expr, root: root}, nil
+}
+
+// MustXPath is like XPath(...) but panics if expression is invalid
+func MustXPath(expr string) *XPathExpr {
+	x, err := XPath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// String returns source of expression
+func (x *XPathExpr) String() string {
+	return x.expr
+}
+
+// Select evaluates expression on node, and returns selected elements and text
+// nodes in document order; returns nil if expression doesn't select nodes.
+// attributes are skipped, see Strings(...)
+func (x *XPathExpr) Select(node *html.Node) []*Element {
+	nodes, ok := x.eval(node).([]xnode)
+	if !ok {
+		return nil
+	}
+
+	var elements []*Element
+	for _, n := range nodes {
+		if n.attr < 0 {
+			elements = append(elements, &Element{n.node})
+		}
+	}
+
+	return elements
+}
+
+// Strings evaluates expression on node, and returns string values of selected
+// nodes (e.g. values of //a/@href), or string value of result if it's not nodes
+func (x *XPathExpr) Strings(node *html.Node) []string {
+	v := x.eval(node)
+
+	nodes, ok := v.([]xnode)
+	if !ok {
+		return []string{xpathString(v)}
+	}
+
+	values := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		values = append(values, n.value())
+	}
+
+	return values
+}
+
+// Value evaluates expression on node, and returns its string value; string value
+// of nodes is value of first one, or empty string if no node is selected
+func (x *XPathExpr) Value(node *html.Node) string {
+	return xpathString(x.eval(node))
+}
+
+func (x *XPathExpr) eval(node *html.Node) xpathValue {
+	ctx := &xpathContext{node: xnode{node, -1}, pos: 1, size: 1, ev: &xpathEvaluator{}}
+	return x.root.eval(ctx)
+}
+
+// xnode is a node of html tree, or attribute attr of node if attr >= 0
+type xnode struct {
+	node *html.Node
+	attr int
+}
+
+// value returns string value of n
+func (n xnode) value() string {
+	if n.attr >= 0 {
+		return n.node.Attr[n.attr].Val
+	}
+
+	if n.node.Type == html.TextNode || n.node.Type == html.CommentNode {
+		return n.node.Data
+	}
+
+	return Element{n.node}.Text()
+}
+
+// name returns name of element or attribute n
+func (n xnode) name() string {
+	if n.attr >= 0 {
+		return n.node.Attr[n.attr].Key
+	}
+
+	if n.node.Type == html.ElementNode {
+		return n.node.Data
+	}
+
+	return ""
+}
+
+// xpathValue is []xnode, string, float64 or bool
+type xpathValue interface{}
+
+type xpathContext struct {
+	node      xnode
+	pos, size int
+	ev        *xpathEvaluator
+}
+
+// xpathEvaluator keeps document order of nodes of an evaluation
+type xpathEvaluator struct {
+	order map[*html.Node]int
+}
+
+// sort sorts nodes in document order and removes duplicates
+func (ev *xpathEvaluator) sort(nodes []xnode) []xnode {
+	if len(nodes) < 2 {
+		return nodes
+	}
+
+	if ev.order == nil {
+		ev.order = make(map[*html.Node]int)
+
+		root := nodes[0].node
+		for root.Parent != nil {
+			root = root.Parent
+		}
+
+		i := 0
+
+		var crawler func(*html.Node)
+		crawler = func(node *html.Node) {
+			ev.order[node] = i
+			i++
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				crawler(child)
+			}
+		}
+
+		crawler(root)
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := ev.order[nodes[i].node], ev.order[nodes[j].node]
+		if a != b {
+			return a < b
+		}
+		return nodes[i].attr < nodes[j].attr
+	})
+
+	unique := nodes[:1]
+	for _, n := range nodes[1:] {
+		if n != unique[len(unique)-1] {
+			unique = append(unique, n)
+		}
+	}
+
+	return unique
+}
+
+// xpathNode is a node of compiled expression
+type xpathNode interface {
+	eval(ctx *xpathContext) xpathValue
+}
+
+type xpathLiteral struct {
+	value xpathValue
+}
+
+func (l *xpathLiteral) eval(ctx *xpathContext) xpathValue {
+	return l.value
+}
+
+// xpathBinary is a binary operator: or, and, =, !=, <, <=, >, >=, +, - and |
+type xpathBinary struct {
+	op          string
+	left, right xpathNode
+}
+
+func (b *xpathBinary) eval(ctx *xpathContext) xpathValue {
+	switch b.op {
+	case "or":
+		return xpathBool(b.left.eval(ctx)) || xpathBool(b.right.eval(ctx))
+
+	case "and":
+		return xpathBool(b.left.eval(ctx)) && xpathBool(b.right.eval(ctx))
+
+	case "+":
+		return xpathNumber(b.left.eval(ctx)) + xpathNumber(b.right.eval(ctx))
+
+	case "-":
+		return xpathNumber(b.left.eval(ctx)) - xpathNumber(b.right.eval(ctx))
+
+	case "|":
+		left, ok1 := b.left.eval(ctx).([]xnode)
+		right, ok2 := b.right.eval(ctx).([]xnode)
+		if !ok1 || !ok2 {
+			return []xnode(nil)
+		}
+
+		return ctx.ev.sort(append(append([]xnode(nil), left...), right...))
+	}
+
+	return xpathCompare(b.op, b.left.eval(ctx), b.right.eval(ctx))
+}
+
+type xpathNegate struct {
+	x xpathNode
+}
+
+func (n *xpathNegate) eval(ctx *xpathContext) xpathValue {
+	return -xpathNumber(n.x.eval(ctx))
+}
+
+// xpathCall is a function call
+type xpathCall struct {
+	name string
+	args []xpathNode
+}
+
+// xpathFuncs are supported functions, and their minimum and maximum number of arguments (-1 is unlimited)
+var xpathFuncs = map[string][2]int{
+	"last":            {0, 0},
+	"position":        {0, 0},
+	"count":           {1, 1},
+	"string":          {0, 1},
+	"normalize-space": {0, 1},
+	"contains":        {2, 2},
+	"starts-with":     {2, 2},
+	"concat":          {2, -1},
+	"string-length":   {0, 1},
+	"name":            {0, 1},
+	"local-name":      {0, 1},
+	"not":             {1, 1},
+	"true":            {0, 0},
+	"false":           {0, 0},
+}
+
+func (c *xpathCall) eval(ctx *xpathContext) xpathValue {
+	// arg returns string value of argument i, or of context node if it's not passed
+	arg := func(i int) string {
+		if i < len(c.args) {
+			return xpathString(c.args[i].eval(ctx))
+		}
+		return ctx.node.value()
+	}
+
+	switch c.name {
+	case "last":
+		return float64(ctx.size)
+
+	case "position":
+		return float64(ctx.pos)
+
+	case "count":
+		nodes, _ := c.args[0].eval(ctx).([]xnode)
+		return float64(len(nodes))
+
+	case "string":
+		return arg(0)
+
+	case "normalize-space":
+		return strings.Join(strings.Fields(arg(0)), " ")
+
+	case "contains":
+		return strings.Contains(arg(0), arg(1))
+
+	case "starts-with":
+		return strings.HasPrefix(arg(0), arg(1))
+
+	case "concat":
+		var b strings.Builder
+		for i := range c.args {
+			b.WriteString(arg(i))
+		}
+		return b.String()
+
+	case "string-length":
+		return float64(len([]rune(arg(0))))
+
+	case "name", "local-name":
+		if len(c.args) == 0 {
+			return ctx.node.name()
+		}
+
+		nodes, _ := c.args[0].eval(ctx).([]xnode)
+		if len(nodes) == 0 {
+			return ""
+		}
+		return nodes[0].name()
+
+	case "not":
+		return !xpathBool(c.args[0].eval(ctx))
+
+	case "true":
+		return true
+
+	case "false":
+		return false
+	}
+
+	return nil
+}
+
+// xpathPath is a location path; or a filter expression (filter with predicates)
+// followed by steps
+type xpathPath struct {
+	abs    bool
+	filter xpathNode
+	preds  []xpathNode
+	steps  []*xpathStep
+}
+
+func (p *xpathPath) eval(ctx *xpathContext) xpathValue {
+	var nodes []xnode
+
+	switch {
+	case p.filter != nil:
+		v := p.filter.eval(ctx)
+		if len(p.preds) == 0 && len(p.steps) == 0 {
+			return v
+		}
+
+		var ok bool
+		if nodes, ok = v.([]xnode); !ok {
+			return []xnode(nil)
+		}
+
+		for _, pred := range p.preds {
+			nodes = xpathFilter(ctx.ev, pred, nodes)
+		}
+
+	case p.abs:
+		root := ctx.node.node
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		nodes = []xnode{{root, -1}}
+
+	default:
+		nodes = []xnode{ctx.node}
+	}
+
+	for _, step := range p.steps {
+		nodes = step.eval(ctx.ev, nodes)
+	}
+
+	return nodes
+}
+
+// xpathStep is a step of location path: axis::test[predicates]
+type xpathStep struct {
+	axis  string
+	kind  string // name, *, text, node or comment
+	name  string
+	preds []xpathNode
+}
+
+// xpathAxes are supported axes; reverse axes are in reverse document order
+var xpathAxes = map[string]bool{
+	"child":              false,
+	"descendant":         false,
+	"descendant-or-self": false,
+	"self":               false,
+	"parent":             true,
+	"ancestor":           true,
+	"ancestor-or-self":   true,
+	"following-sibling":  false,
+	"preceding-sibling":  true,
+	"attribute":          false,
+}
+
+func (s *xpathStep) eval(ev *xpathEvaluator, nodes []xnode) []xnode {
+	var result []xnode
+
+	for _, n := range nodes {
+		var selected []xnode
+
+		s.axisNodes(n, func(c xnode) {
+			if s.test(c) {
+				selected = append(selected, c)
+			}
+		})
+
+		for _, pred := range s.preds {
+			selected = xpathFilter(ev, pred, selected)
+		}
+
+		result = append(result, selected...)
+	}
+
+	return ev.sort(result)
+}
+
+// axisNodes calls f with nodes of axis of n, in axis order
+func (s *xpathStep) axisNodes(n xnode, f func(xnode)) {
+	node := n.node
+
+	if n.attr >= 0 {
+		switch s.axis {
+		case "self", "descendant-or-self":
+			f(n)
+		case "ancestor-or-self":
+			f(n)
+			fallthrough
+		case "ancestor":
+			for p := node; p != nil; p = p.Parent {
+				f(xnode{p, -1})
+			}
+		case "parent":
+			f(xnode{node, -1})
+		}
+		return
+	}
+
+	var descendants func(*html.Node)
+	descendants = func(node *html.Node) {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			f(xnode{c, -1})
+			descendants(c)
+		}
+	}
+
+	switch s.axis {
+	case "child":
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			f(xnode{c, -1})
+		}
+
+	case "descendant-or-self":
+		f(n)
+		fallthrough
+	case "descendant":
+		descendants(node)
+
+	case "self":
+		f(n)
+
+	case "parent":
+		if node.Parent != nil {
+			f(xnode{node.Parent, -1})
+		}
+
+	case "ancestor-or-self":
+		f(n)
+		fallthrough
+	case "ancestor":
+		for p := node.Parent; p != nil; p = p.Parent {
+			f(xnode{p, -1})
+		}
+
+	case "following-sibling":
+		for c := node.NextSibling; c != nil; c = c.NextSibling {
+			f(xnode{c, -1})
+		}
+
+	case "preceding-sibling":
+		for c := node.PrevSibling; c != nil; c = c.PrevSibling {
+			f(xnode{c, -1})
+		}
+
+	case "attribute":
+		if node.Type == html.ElementNode {
+			for i := range node.Attr {
+				f(xnode{node, i})
+			}
+		}
+	}
+}
+
+// test returns true if n passes node test of step
+func (s *xpathStep) test(n xnode) bool {
+	switch s.kind {
+	case "node":
+		return true
+
+	case "text":
+		return n.attr < 0 && n.node.Type == html.TextNode
+
+	case "comment":
+		return n.attr < 0 && n.node.Type == html.CommentNode
+	}
+
+	// principal node type of attribute axis is attribute, and of other axes is element
+	if s.axis == "attribute" {
+		if n.attr < 0 {
+			return false
+		}
+	} else if n.attr >= 0 || n.node.Type != html.ElementNode {
+		return false
+	}
+
+	return s.kind == "*" || n.name() == s.name
+}
+
+// xpathFilter returns nodes which pass predicate pred; a number predicate
+// is compared with position of node
+func xpathFilter(ev *xpathEvaluator, pred xpathNode, nodes []xnode) []xnode {
+	var result []xnode
+
+	for i, n := range nodes {
+		v := pred.eval(&xpathContext{node: n, pos: i + 1, size: len(nodes), ev: ev})
+
+		if num, ok := v.(float64); ok {
+			if num == float64(i+1) {
+				result = append(result, n)
+			}
+		} else if xpathBool(v) {
+			result = append(result, n)
+		}
+	}
+
+	return result
+}
+
+// xpathCompare compares a and b by op (=, !=, <, <=, >, >=), like xpath 1.0
+func xpathCompare(op string, a, b xpathValue) bool {
+	an, aNodes := a.([]xnode)
+	bn, bNodes := b.([]xnode)
+
+	switch {
+	case aNodes && bNodes:
+		for _, x := range an {
+			for _, y := range bn {
+				if xpathCompare(op, x.value(), y.value()) {
+					return true
+				}
+			}
+		}
+		return false
+
+	case aNodes || bNodes:
+		nodes, other, swapped := an, b, false
+		if bNodes {
+			nodes, other, swapped = bn, a, true
+		}
+
+		if v, ok := other.(bool); ok {
+			if swapped {
+				return xpathCompare(op, v, len(nodes) != 0)
+			}
+			return xpathCompare(op, len(nodes) != 0, v)
+		}
+
+		for _, n := range nodes {
+			var v xpathValue = n.value()
+			if _, ok := other.(float64); ok {
+				v = xpathNumber(v)
+			}
+
+			if swapped && xpathCompare(op, other, v) || !swapped && xpathCompare(op, v, other) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if op == "=" || op == "!=" {
+		var equal bool
+
+		_, aBool := a.(bool)
+		_, bBool := b.(bool)
+		_, aNum := a.(float64)
+		_, bNum := b.(float64)
+
+		switch {
+		case aBool || bBool:
+			equal = xpathBool(a) == xpathBool(b)
+		case aNum || bNum:
+			equal = xpathNumber(a) == xpathNumber(b)
+		default:
+			equal = xpathString(a) == xpathString(b)
+		}
+
+		return equal == (op == "=")
+	}
+
+	x, y := xpathNumber(a), xpathNumber(b)
+
+	switch op {
+	case "<":
+		return x < y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	case ">=":
+		return x >= y
+	}
+
+	return false
+}
+
+func xpathString(v xpathValue) string {
+	switch v := v.(type) {
+	case string:
+		return v
+
+	case bool:
+		return strconv.FormatBool(v)
+
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 0):
+			if v > 0 {
+				return "Infinity"
+			}
+			return "-Infinity"
+		case v == math.Trunc(v):
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+
+	case []xnode:
+		if len(v) == 0 {
+			return ""
+		}
+		return v[0].value()
+	}
+
+	return ""
+}
+
+func xpathNumber(v xpathValue) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(xpathString(v)), 64)
+	if err != nil {
+		return math.NaN()
+	}
+
+	return n
+}
+
+func xpathBool(v xpathValue) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	case []xnode:
+		return len(v) != 0
+	}
+
+	return false
+}
+
+// xpathToken is a token of expression; kind is 'n' (name), 's' (string
+// literal), 'd' (number), 'o' (operator) or 0 (end)
+type xpathToken struct {
+	kind byte
+	s    string
+	pos  int
+}
+
+type xpathParser struct {
+	expr string
+	toks []xpathToken
+	i    int
+}
+
+func (p *xpathParser) errorf(pos int, format string, args ...interface{}) error {
+	return &XPathError{Expr: p.expr, Offset: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *xpathParser) tokenize() error {
+	s := p.expr
+
+	for i := 0; i < len(s); {
+		ch := s[i]
+
+		switch {
+		case isSpace(ch):
+			i++
+			continue
+
+		case ch == '"' || ch == '\'':
+			end := strings.IndexByte(s[i+1:], ch)
+			if end < 0 {
+				return p.errorf(i, "unterminated string")
+			}
+
+			p.toks = append(p.toks, xpathToken{'s', s[i+1 : i+1+end], i})
+			i += end + 2
+			continue
+
+		case ch >= '0' && ch <= '9' || ch == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			start := i
+			for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+
+			p.toks = append(p.toks, xpathToken{'d', s[start:i], start})
+			continue
+
+		case isNameStart(ch):
+			start := i
+			for i < len(s) && (isNameStart(s[i]) || s[i] == '-' || s[i] >= '0' && s[i] <= '9') {
+				i++
+			}
+
+			p.toks = append(p.toks, xpathToken{'n', s[start:i], start})
+			continue
+		}
+
+		op := ""
+		for _, o := range []string{"//", "..", "::", "!=", "<=", ">=", "/", ".", "(", ")", "[", "]", "@", ",", "|", "+", "-", "=", "<", ">", "*"} {
+			if strings.HasPrefix(s[i:], o) {
+				op = o
+				break
+			}
+		}
+
+		if op == "" {
+			return p.errorf(i, "unexpected %q", ch)
+		}
+
+		p.toks = append(p.toks, xpathToken{'o', op, i})
+		i += len(op)
+	}
+
+	return nil
+}
+
+func (p *xpathParser) peek() xpathToken {
+	return p.peekAt(0)
+}
+
+func (p *xpathParser) peekAt(n int) xpathToken {
+	if p.i+n < len(p.toks) {
+		return p.toks[p.i+n]
+	}
+	return xpathToken{pos: len(p.expr)}
+}
+
+func (p *xpathParser) next() xpathToken {
+	t := p.peek()
+	if p.i < len(p.toks) {
+		p.i++
+	}
+	return t
+}
+
+// isOp returns true if next token is operator op
+func (p *xpathParser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == 'o' && t.s == op
+}
+
+func (p *xpathParser) expect(op string) error {
+	if !p.isOp(op) {
+		t := p.peek()
+		if t.kind == 0 {
+			return p.errorf(t.pos, "expected %q, found end of expression", op)
+		}
+		return p.errorf(t.pos, "expected %q, found %q", op, t.s)
+	}
+
+	p.i++
+	return nil
+}
+
+// parseBinary parses operands of parse joined by operators of ops, left-associative
+func (p *xpathParser) parseBinary(parse func() (xpathNode, error), ops ...string) (xpathNode, error) {
+	left, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+
+		op := ""
+		for _, o := range ops {
+			if (t.kind == 'o' || t.kind == 'n') && t.s == o {
+				op = o
+			}
+		}
+
+		if op == "" {
+			return left, nil
+		}
+
+		p.i++
+
+		right, err := parse()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &xpathBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *xpathParser) parseOr() (xpathNode, error) {
+	return p.parseBinary(p.parseAnd, "or")
+}
+
+func (p *xpathParser) parseAnd() (xpathNode, error) {
+	return p.parseBinary(p.parseEquality, "and")
+}
+
+func (p *xpathParser) parseEquality() (xpathNode, error) {
+	return p.parseBinary(p.parseRelational, "=", "!=")
+}
+
+func (p *xpathParser) parseRelational() (xpathNode, error) {
+	return p.parseBinary(p.parseAdditive, "<", "<=", ">", ">=")
+}
+
+func (p *xpathParser) parseAdditive() (xpathNode, error) {
+	return p.parseBinary(p.parseUnary, "+", "-")
+}
+
+func (p *xpathParser) parseUnary() (xpathNode, error) {
+	if p.isOp("-") {
+		p.i++
+
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &xpathNegate{x}, nil
+	}
+
+	return p.parseBinary(p.parsePath, "|")
+}
+
+// parsePath parses a location path, or a filter expression
+func (p *xpathParser) parsePath() (xpathNode, error) {
+	t := p.peek()
+
+	filter := t.kind == 's' || t.kind == 'd' || t.kind == 'o' && t.s == "("
+	if t.kind == 'n' && p.peekAt(1).s == "(" && !isNodeType(t.s) {
+		filter = true
+	}
+
+	if !filter {
+		return p.parseLocationPath()
+	}
+
+	primary, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	path := &xpathPath{filter: primary}
+
+	for p.isOp("[") {
+		pred, err := p.parsePredicate()
+		if err != nil {
+			return nil, err
+		}
+		path.preds = append(path.preds, pred)
+	}
+
+	if p.isOp("/") || p.isOp("//") {
+		if err := p.parseSteps(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return path, nil
+}
+
+func (p *xpathParser) parsePrimary() (xpathNode, error) {
+	t := p.next()
+
+	switch t.kind {
+	case 's':
+		return &xpathLiteral{t.s}, nil
+
+	case 'd':
+		n, err := strconv.ParseFloat(t.s, 64)
+		if err != nil {
+			return nil, p.errorf(t.pos, "invalid number %q", t.s)
+		}
+		return &xpathLiteral{n}, nil
+
+	case 'o':
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		return x, p.expect(")")
+	}
+
+	// function call
+	limits, ok := xpathFuncs[t.s]
+	if !ok {
+		return nil, p.errorf(t.pos, "unknown function %s()", t.s)
+	}
+
+	p.i++ // (
+
+	call := &xpathCall{name: t.s}
+
+	for !p.isOp(")") {
+		if len(call.args) != 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		call.args = append(call.args, arg)
+	}
+
+	p.i++ // )
+
+	if len(call.args) < limits[0] || limits[1] >= 0 && len(call.args) > limits[1] {
+		return nil, p.errorf(t.pos, "wrong number of arguments of %s()", t.s)
+	}
+
+	return call, nil
+}
+
+func (p *xpathParser) parseLocationPath() (xpathNode, error) {
+	path := &xpathPath{}
+
+	switch {
+	case p.isOp("/"):
+		path.abs = true
+		p.i++
+
+		// "/" selects root
+		if !p.canStartStep() {
+			return path, nil
+		}
+
+	case p.isOp("//"):
+		path.abs = true
+		return path, p.parseSteps(path)
+	}
+
+	step, err := p.parseStep()
+	if err != nil {
+		return nil, err
+	}
+
+	path.steps = append(path.steps, step)
+
+	return path, p.parseSteps(path)
+}
+
+// parseSteps parses ('/' step | '//' step)*
+func (p *xpathParser) parseSteps(path *xpathPath) error {
+	for p.isOp("/") || p.isOp("//") {
+		if p.next().s == "//" {
+			path.steps = append(path.steps, &xpathStep{axis: "descendant-or-self", kind: "node"})
+		}
+
+		step, err := p.parseStep()
+		if err != nil {
+			return err
+		}
+
+		path.steps = append(path.steps, step)
+	}
+
+	return nil
+}
+
+func (p *xpathParser) canStartStep() bool {
+	t := p.peek()
+	return t.kind == 'n' || t.kind == 'o' && (t.s == "*" || t.s == "." || t.s == ".." || t.s == "@")
+}
+
+func (p *xpathParser) parseStep() (*xpathStep, error) {
+	if p.isOp(".") {
+		p.i++
+		return &xpathStep{axis: "self", kind: "node"}, nil
+	}
+
+	if p.isOp("..") {
+		p.i++
+		return &xpathStep{axis: "parent", kind: "node"}, nil
+	}
+
+	step := &xpathStep{axis: "child"}
+
+	if p.isOp("@") {
+		p.i++
+		step.axis = "attribute"
+	} else if t := p.peek(); t.kind == 'n' && p.peekAt(1).s == "::" {
+		if _, ok := xpathAxes[t.s]; !ok {
+			return nil, p.errorf(t.pos, "unknown axis %s", t.s)
+		}
+
+		step.axis = t.s
+		p.i += 2
+	}
+
+	t := p.next()
+
+	switch {
+	case t.kind == 'o' && t.s == "*":
+		step.kind = "*"
+
+	case t.kind == 'n' && isNodeType(t.s) && p.isOp("("):
+		p.i++
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		step.kind = t.s
+
+	case t.kind == 'n':
+		step.kind = "name"
+		step.name = strings.ToLower(t.s)
+
+	case t.kind == 0:
+		return nil, p.errorf(t.pos, "expected step, found end of expression")
+
+	default:
+		return nil, p.errorf(t.pos, "expected step, found %q", t.s)
+	}
+
+	for p.isOp("[") {
+		pred, err := p.parsePredicate()
+		if err != nil {
+			return nil, err
+		}
+		step.preds = append(step.preds, pred)
+	}
+
+	return step, nil
+}
+
+func (p *xpathParser) parsePredicate() (xpathNode, error) {
+	p.i++ // [
+
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	return pred, p.expect("]")
+}
+
+func isNodeType(name string) bool {
+	return name == "text" || name == "node" || name == "comment"
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || ch >= 0x80 || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
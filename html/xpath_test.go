@@ -0,0 +1,181 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXPathSelect(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	for _, tt := range []struct {
+		expr, want string
+	}{
+		// location paths
+		{"/html", "root"},
+		{"/html/body/div", "main side"},
+		{"//li", "li1 li2 li3 li4"},
+		{"//ul/li/span", "s1"},
+		{"//div//a", "a1 a2"},
+		{"//*[@id='list']/*", "li1 li2 li3 li4"},
+		{"//span/..", "li4 side"},
+		{"//h1/.", "h1"},
+		{"//p | //h1", "h1 p1 p2 p3"},
+		{"//missing", ""},
+
+		// axes
+		{"//li[1]/following-sibling::li", "li2 li3 li4"},
+		{"//li[3]/preceding-sibling::*", "li1 li2"},
+		{"//s1/ancestor::*", ""},
+		{"//span[@id='s1']/ancestor::*", "root body main list li4"},
+		{"//span[@id='s1']/ancestor-or-self::li", "li4"},
+		{"//ul/descendant::*", "li1 li2 li3 a2 li4 s1"},
+		{"//ul/descendant-or-self::ul", "list"},
+		{"//li/self::li[@class]", "li2"},
+		{"//a/parent::p", "p1"},
+		{"//a/child::node()/..", "a1 a2"},
+
+		// predicates
+		{"//li[2]", "li2"},
+		{"//li[last()]", "li4"},
+		{"//li[last() - 1]", "li3"},
+		{"//li[position() < 3]", "li1 li2"},
+		{"//li[position() >= 2][position() <= 2]", "li2 li3"},
+		{"(//a)[2]", "a2"},
+		{"//p[@class]", "p1 p2"},
+		{"//p[not(@class)]", "p3"},
+		{"//div[@lang='en']", "side"},
+		{"//div[@lang!='en']", "main"},
+		{"//li[a]", "li3"},
+		{"//li[span or a]", "li3 li4"},
+		{"//li[@class and text()='2']", "li2"},
+		{"//li[. = '1']", "li1"},
+		{"//li[. > 2]", "li4"},
+		{"//*[@id = 'h1' or @id = 'p3']", "h1 p3"},
+		{"//li[-1 + 3]", "li2"},
+
+		// functions
+		{"//p[contains(@class, 'intro')]", "p1"},
+		{"//a[starts-with(@href, 'http')]", "a1"},
+		{"//li[normalize-space() = '3 two']", "li3"},
+		{"//li[string-length(string()) > 1]", "li3"},
+		{"//*[name() = 'span']", "s1 s2"},
+		{"//*[local-name() = 'h1']", "h1"},
+		{"//ul[count(li) = 4]", "list"},
+		{"//div[count(.//span) = 1]", "main side"},
+		{"//li[concat(@id, '-', @class) = 'li2-odd']", "li2"},
+		{"//li[true()]", "li1 li2 li3 li4"},
+		{"//li[false()]", ""},
+	} {
+		x, err := XPath(tt.expr)
+		if err != nil {
+			t.Errorf("XPath(%q) error = %v", tt.expr, err)
+			continue
+		}
+
+		if got := ids(doc.Select(x)); got != tt.want {
+			t.Errorf("Select(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestXPathStrings(t *testing.T) {
+	doc := parseTestDocument(t)
+
+	for _, tt := range []struct {
+		expr string
+		want []string
+	}{
+		{"//a/@href", []string{"https://example.com/one", "/url?q=two"}},
+		{"//li/text()", []string{"1", "2", "3 "}},
+		{"//div/@*", []string{"main", "box main", "en-US", "side", "en"}},
+		{"//li[@class]/attribute::class", []string{"odd"}},
+		{"//h1", []string{"Header"}},
+		{"count(//li)", []string{"4"}},
+		{"//title = 'Test'", []string{"true"}},
+		{"//missing/@id", nil},
+	} {
+		got := doc.SelectStrings(MustXPath(tt.expr))
+
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("SelectStrings(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestXPathValue(t *testing.T) {
+	doc := parseTestDocument(t)
+	root := doc.Find(&Match{Name: "html"}).Node.Parent
+
+	for _, tt := range []struct {
+		expr, want string
+	}{
+		{"//title", "Test"},
+		{"//li", "1"},
+		{"string(//a/@href)", "https://example.com/one"},
+		{"normalize-space(' a  b ')", "a b"},
+		{"concat('a', 'b', 'c')", "abc"},
+		{"count(//p) + 1", "4"},
+		{"-count(//p)", "-3"},
+		{"string-length('abc')", "3"},
+		{"1 < 2", "true"},
+		{"'1' = 1", "true"},
+		{"//li = '3 two'", "true"},
+		{"//li != '1'", "true"},
+		{"not(//missing)", "true"},
+		{"name(//*[@id='s2'])", "span"},
+		{"1.5 + 1", "2.5"},
+		{"//missing", ""},
+	} {
+		if got := MustXPath(tt.expr).Value(root); got != tt.want {
+			t.Errorf("Value(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestXPathRelative(t *testing.T) {
+	doc := parseTestDocument(t)
+	list := doc.Find(&Match{Attributes: map[string]string{"id": "list"}})
+
+	for _, tt := range []struct {
+		expr, want string
+	}{
+		{"li", "li1 li2 li3 li4"},
+		{"./li[a]", "li3"},
+		{".//span", "s1"},
+		{"../h1", "h1"},
+		{"//div", "main side"},
+	} {
+		if got := ids(list.Select(MustXPath(tt.expr))); got != tt.want {
+			t.Errorf("Select(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestXPathError(t *testing.T) {
+	for _, tt := range []struct {
+		expr   string
+		offset int
+		msg    string
+	}{
+		{"", 0, "expected step, found end of expression"},
+		{"//a)", 3, `unexpected ")"`},
+		{"/a/#", 3, `unexpected '#'`},
+		{"foo()", 0, "unknown function foo()"},
+		{"count()", 0, "wrong number of arguments of count()"},
+		{"bogus::a", 0, "unknown axis bogus"},
+		{`//a[@x='y]`, 7, "unterminated string"},
+	} {
+		_, err := XPath(tt.expr)
+
+		e, ok := err.(*XPathError)
+		if !ok {
+			t.Errorf("XPath(%q) error = %v, want *XPathError", tt.expr, err)
+			continue
+		}
+
+		if e.Offset != tt.offset || e.Msg != tt.msg {
+			t.Errorf("XPath(%q) error at %d %q, want at %d %q", tt.expr, e.Offset, e.Msg, tt.offset, tt.msg)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package dorkali
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Jar is a cookie jar backed by net/http/cookiejar, which remembers its
+// cookies to save them to disk and load them in next runs.
+type Jar struct {
+	jar *cookiejar.Jar
+
+	mu      sync.Mutex
+	entries map[string]*JarCookie
+}
+
+// JarCookie is a stored cookie
+type JarCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"`
+
+	// HostOnly is true if cookie is sent only to Domain, not its subdomains
+	HostOnly bool `json:"host_only,omitempty"`
+}
+
+// NewJar returns an empty jar; cookies of public suffixes (e.g. co.uk) are rejected
+func NewJar() *Jar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return &Jar{jar: jar, entries: make(map[string]*JarCookie)}
+}
+
+// SetCookies implements http.CookieJar; cookies which the jar rejects (e.g. a
+// cookie of another domain) are not remembered
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+
+	for _, c := range cookies {
+		domain, hostOnly, ok := cookieDomain(host, c.Domain)
+		if !ok {
+			continue
+		}
+
+		e := &JarCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			HostOnly: hostOnly,
+		}
+
+		if e.Path == "" || e.Path[0] != '/' {
+			e.Path = defaultPath(u.Path)
+		}
+
+		key := e.key()
+
+		switch {
+		case c.MaxAge < 0:
+			delete(j.entries, key)
+			continue
+		case c.MaxAge > 0:
+			e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			if !c.Expires.After(now) {
+				delete(j.entries, key)
+				continue
+			}
+			e.Expires = c.Expires
+		}
+
+		j.entries[key] = e
+	}
+}
+
+// Cookies implements http.CookieJar
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// All returns all unexpired cookies of jar, sorted by domain, path and name
+func (j *Jar) All() []*JarCookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	all := make([]*JarCookie, 0, len(j.entries))
+
+	for key, e := range j.entries {
+		if !e.Expires.IsZero() && !e.Expires.After(now) {
+			delete(j.entries, key)
+			continue
+		}
+
+		cp := *e
+		all = append(all, &cp)
+	}
+
+	sort.Slice(all, func(a, b int) bool {
+		return all[a].key() < all[b].key()
+	})
+
+	return all
+}
+
+// Add adds stored cookies to jar
+func (j *Jar) Add(cookies ...*JarCookie) {
+	for _, e := range cookies {
+		domain := strings.TrimPrefix(e.Domain, ".")
+		if domain == "" || e.Name == "" {
+			continue
+		}
+
+		c := &http.Cookie{
+			Name:     e.Name,
+			Value:    e.Value,
+			Path:     e.Path,
+			Expires:  e.Expires,
+			Secure:   e.Secure,
+			HttpOnly: e.HttpOnly,
+		}
+
+		if !e.HostOnly {
+			c.Domain = domain
+		}
+
+		path := e.Path
+		if path == "" {
+			path = "/"
+		}
+
+		j.SetCookies(&url.URL{Scheme: "https", Host: domain, Path: path}, []*http.Cookie{c})
+	}
+}
+
+// Load reads cookies which saved by Save(...) from filename
+func (j *Jar) Load(filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var cookies []*JarCookie
+	if err := json.Unmarshal(b, &cookies); err != nil {
+		return fmt.Errorf("dorkali: invalid cookie file %q: %w", filename, err)
+	}
+
+	j.Add(cookies...)
+	return nil
+}
+
+// Save writes all cookies to filename as JSON, and creates its directory if not exists
+func (j *Jar) Save(filename string) error {
+	b, err := json.MarshalIndent(j.All(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return err
+	}
+
+	return writeFile(filename, b)
+}
+
+// ImportNetscape reads cookies from a Netscape cookies.txt file, which
+// browsers extensions and curl export
+func (j *Jar) ImportNetscape(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var cookies []*JarCookie
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = line[len("#HttpOnly_"):]
+			httpOnly = true
+		}
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("dorkali: invalid cookies.txt line %d", n)
+		}
+
+		e := &JarCookie{
+			Domain:   fields[0],
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+
+		if strings.HasPrefix(e.Domain, ".") {
+			e.HostOnly = false
+		}
+
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("dorkali: invalid cookies.txt line %d: %w", n, err)
+		}
+
+		if expires > 0 {
+			e.Expires = time.Unix(expires, 0)
+		}
+
+		cookies = append(cookies, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	j.Add(cookies...)
+	return nil
+}
+
+// ExportNetscape writes all cookies in Netscape cookies.txt format
+func (j *Jar) ExportNetscape(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("# Netscape HTTP Cookie File\n# exported by dorkali\n\n")
+
+	for _, e := range j.All() {
+		domain := e.Domain
+		subdomains := "FALSE"
+
+		if !e.HostOnly {
+			domain = "." + domain
+			subdomains = "TRUE"
+		}
+
+		if e.HttpOnly {
+			domain = "#HttpOnly_" + domain
+		}
+
+		secure := "FALSE"
+		if e.Secure {
+			secure = "TRUE"
+		}
+
+		var expires int64
+		if !e.Expires.IsZero() {
+			expires = e.Expires.Unix()
+		}
+
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, subdomains, e.Path, secure, expires, e.Name, e.Value)
+	}
+
+	return bw.Flush()
+}
+
+// cookieDomain returns domain of a cookie which host sets with domain attribute,
+// and whether it's host-only; returns false if cookie is rejected, like cookiejar
+// does (RFC 6265 section 5.3 steps 5 and 6)
+func cookieDomain(host, domain string) (string, bool, bool) {
+	if domain == "" {
+		return host, true, true
+	}
+
+	if net.ParseIP(host) != nil {
+		return host, true, host == domain
+	}
+
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if domain == "" || domain[0] == '.' || domain[len(domain)-1] == '.' {
+		return "", false, false
+	}
+
+	if ps, _ := publicsuffix.PublicSuffix(domain); ps != "" && !strings.HasSuffix(domain, "."+ps) {
+		// cookie of a public suffix is accepted only as host-only cookie of it
+		return host, true, host == domain
+	}
+
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		return "", false, false
+	}
+
+	return domain, false, true
+}
+
+func (e *JarCookie) key() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+// defaultPath returns default path of cookie, as RFC 6265 section 5.1.4
+func defaultPath(path string) string {
+	if len(path) == 0 || path[0] != '/' {
+		return "/"
+	}
+
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+
+	return path[:i]
+}
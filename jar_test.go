@@ -0,0 +1,101 @@
+package dorkali
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func mustURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestJarRejectedCookies(t *testing.T) {
+	j := NewJar()
+
+	j.SetCookies(mustURL(t, "https://www.bing.com/search"), []*http.Cookie{
+		{Name: "evil", Value: "1", Domain: "google.com"},
+		{Name: "suffix", Value: "1", Domain: "com"},
+		{Name: "ok", Value: "1", Domain: ".bing.com"},
+		{Name: "host", Value: "1"},
+	})
+
+	j.SetCookies(mustURL(t, "https://127.0.0.1/"), []*http.Cookie{
+		{Name: "ip", Value: "1", Domain: "127.0.0.2"},
+	})
+
+	all := j.All()
+
+	var names []string
+	for _, c := range all {
+		names = append(names, c.Domain+" "+c.Name)
+	}
+
+	if len(all) != 2 || names[0] != "bing.com ok" || names[1] != "www.bing.com host" {
+		t.Fatalf("All() = %q, want [bing.com ok, www.bing.com host]", names)
+	}
+
+	if !all[1].HostOnly || all[0].HostOnly {
+		t.Errorf("HostOnly = %v, %v, want false, true", all[0].HostOnly, all[1].HostOnly)
+	}
+
+	// saved cookies are loaded as they were accepted
+	filename := filepath.Join(t.TempDir(), "cookies.json")
+	if err := j.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	j2 := NewJar()
+	if err := j2.Load(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		url  string
+		want int
+	}{
+		{"https://www.google.com/", 0},
+		{"https://example.com/", 0},
+		{"https://www.bing.com/", 2},
+		{"https://cn.bing.com/", 1},
+	} {
+		if got := j2.Cookies(mustURL(t, tt.url)); len(got) != tt.want {
+			t.Errorf("Cookies(%s) = %v, want %d cookies", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestCookieDomain(t *testing.T) {
+	for _, tt := range []struct {
+		host, domain string
+		want         string
+		hostOnly, ok bool
+	}{
+		{"www.example.com", "", "www.example.com", true, true},
+		{"www.example.com", "example.com", "example.com", false, true},
+		{"www.example.com", ".Example.COM", "example.com", false, true},
+		{"www.example.com", "www.example.com", "www.example.com", false, true},
+		{"www.example.com", "other.com", "", false, false},
+		{"www.example.com", "ample.com", "", false, false},
+		{"www.example.com", "sub.www.example.com", "", false, false},
+		{"www.example.com", "com", "", false, false},
+		{"www.example.co.uk", "co.uk", "", false, false},
+		{"co.uk", "co.uk", "co.uk", true, true},
+		{"www.example.com", "example.com.", "", false, false},
+		{"127.0.0.1", "127.0.0.1", "127.0.0.1", true, true},
+		{"127.0.0.1", ".127.0.0.1", "", false, false},
+	} {
+		domain, hostOnly, ok := cookieDomain(tt.host, tt.domain)
+		if ok != tt.ok || (ok && (domain != tt.want || hostOnly != tt.hostOnly)) {
+			t.Errorf("cookieDomain(%q, %q) = %q, %v, %v, want %q, %v, %v",
+				tt.host, tt.domain, domain, hostOnly, ok, tt.want, tt.hostOnly, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package dorkali
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoMoreResults is returned by engines (with results of the page) when
+// there is no next page, and by Paginator when pagination is finished.
+var ErrNoMoreResults = errors.New("dorkali: no more results")
+
+// Paginator fetches pages of a request one after another, deduplicates
+// results by url, and stops when limit reached, a page yields nothing new,
+// or engine signals the end.
+//
+// Example:
+//
+//	p := dorkali.NewPaginator(engine, &dorkali.SearchRequest{Query: "github"}, 50)
+//	for {
+//		results, err := p.Next(ctx)
+//		if err == dorkali.ErrNoMoreResults {
+//			break
+//		}
+//		// handle error ...
+//	}
+type Paginator struct {
+	engine Engine
+	req    SearchRequest
+	limit  int
+
+	seen  map[string]struct{}
+	count int
+	done  bool
+}
+
+// NewPaginator returns a paginator which starts from req.Page, and
+// returns at most limit results. limit <= 0 means no limit.
+func NewPaginator(engine Engine, req *SearchRequest, limit int) *Paginator {
+	return &Paginator{
+		engine: engine,
+		req:    *req,
+		limit:  limit,
+		seen:   make(map[string]struct{}),
+	}
+}
+
+// Resume marks urls as already returned, to continue an interrupted
+// pagination; they are not returned again and count toward limit.
+//
+// it should be called before first call of Next(...)
+func (p *Paginator) Resume(urls []string) {
+	for _, u := range urls {
+		if _, ok := p.seen[u]; !ok {
+			p.seen[u] = struct{}{}
+			p.count++
+		}
+	}
+
+	if p.limit > 0 && p.count >= p.limit {
+		p.done = true
+	}
+}
+
+// Page returns page number which next call of Next(...) fetches
+func (p *Paginator) Page() int {
+	return p.req.Page
+}
+
+// Count returns number of results returned so far
+func (p *Paginator) Count() int {
+	return p.count
+}
+
+// Done returns true if pagination is finished
+func (p *Paginator) Done() bool {
+	return p.done
+}
+
+// Next fetches next page and returns its new results.
+//
+// returns ErrNoMoreResults when pagination is finished
+func (p *Paginator) Next(ctx context.Context) ([]Result, error) {
+	if p.done {
+		return nil, ErrNoMoreResults
+	}
+
+	req := p.req
+
+	response, err := p.engine.SearchContext(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := p.engine.ParseResponseContext(ctx, response)
+	if err == ErrNoMoreResults {
+		p.done = true
+	} else if err != nil {
+		return nil, err
+	}
+
+	p.req.Page++
+
+	var fresh []Result
+
+	for _, r := range results {
+		if p.limit > 0 && p.count >= p.limit {
+			break
+		}
+
+		u := r.Url()
+		if u == "" {
+			continue
+		}
+
+		if _, ok := p.seen[u]; ok {
+			continue
+		}
+
+		p.seen[u] = struct{}{}
+		p.count++
+		fresh = append(fresh, r)
+	}
+
+	if len(fresh) == 0 {
+		p.done = true
+		return nil, ErrNoMoreResults
+	}
+
+	if p.limit > 0 && p.count >= p.limit {
+		p.done = true
+	}
+
+	return fresh, nil
+}
+
+// Paginate fetches pages until n results collected or pagination finished
+func Paginate(ctx context.Context, engine Engine, req *SearchRequest, n int) ([]Result, error) {
+	p := NewPaginator(engine, req, n)
+
+	var results []Result
+
+	for {
+		r, err := p.Next(ctx)
+		if err == ErrNoMoreResults {
+			return results, nil
+		}
+
+		if err != nil {
+			return results, err
+		}
+
+		results = append(results, r...)
+	}
+}
+
+// Paginate fetches pages until n results collected or pagination finished
+func (a *API) Paginate(ctx context.Context, req *SearchRequest, n int) ([]Result, error) {
+	return Paginate(ctx, a, req, n)
+}
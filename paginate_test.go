@@ -0,0 +1,208 @@
+package dorkali
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type fakeResult string
+
+func (r fakeResult) Title() string       { return string(r) }
+func (r fakeResult) Description() string { return "" }
+func (r fakeResult) Url() string         { return string(r) }
+func (r fakeResult) String() string      { return string(r) }
+
+// fakeEngine returns urls of pages[req.Page]; ErrNoMoreResults is returned with
+// the last page if last is true, and for pages after it
+type fakeEngine struct {
+	pages [][]string
+	last  bool
+
+	// requested pages
+	requests []int
+}
+
+func (e *fakeEngine) Start() error           { return nil }
+func (e *fakeEngine) Version() string        { return "" }
+func (e *fakeEngine) Description() string    { return "" }
+func (e *fakeEngine) Flags(fs *flag.FlagSet) {}
+func (e *fakeEngine) Usage()                 {}
+func (e *fakeEngine) SetSession(s *Session)  {}
+func (e *fakeEngine) Session() *Session      { return nil }
+
+func (e *fakeEngine) ParseHTML(h string) ([]Result, error) { return nil, nil }
+
+func (e *fakeEngine) Search(req *SearchRequest) (*http.Response, error) {
+	return e.SearchContext(context.Background(), req)
+}
+
+func (e *fakeEngine) SearchContext(ctx context.Context, req *SearchRequest) (*http.Response, error) {
+	e.requests = append(e.requests, req.Page)
+	return &http.Response{Header: http.Header{"Page": {strconv.Itoa(req.Page)}}}, nil
+}
+
+func (e *fakeEngine) ParseResponse(response *http.Response) ([]Result, error) {
+	return e.ParseResponseContext(context.Background(), response)
+}
+
+func (e *fakeEngine) ParseResponseContext(ctx context.Context, response *http.Response) ([]Result, error) {
+	page, _ := strconv.Atoi(response.Header.Get("Page"))
+	if page >= len(e.pages) {
+		return nil, ErrNoMoreResults
+	}
+
+	var results []Result
+	for _, u := range e.pages[page] {
+		results = append(results, fakeResult(u))
+	}
+
+	if e.last && page == len(e.pages)-1 {
+		return results, ErrNoMoreResults
+	}
+
+	return results, nil
+}
+
+// paginate returns urls of all pages of p, separated by '|' between pages
+func paginate(t *testing.T, p *Paginator) string {
+	t.Helper()
+
+	var pages []string
+
+	for {
+		results, err := p.Next(context.Background())
+		if err == ErrNoMoreResults {
+			return strings.Join(pages, "|")
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var urls []string
+		for _, r := range results {
+			urls = append(urls, r.Url())
+		}
+
+		pages = append(pages, strings.Join(urls, " "))
+	}
+}
+
+func TestPaginator(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		pages    [][]string
+		last     bool
+		start    int
+		limit    int
+		resume   []string
+		want     string
+		requests string
+		count    int
+	}{
+		{
+			name:     "dedupe",
+			pages:    [][]string{{"a", "b", "b"}, {"b", "c", ""}, {"d"}},
+			last:     true,
+			want:     "a b|c|d",
+			requests: "0 1 2",
+			count:    4,
+		},
+		{
+			name:     "exact limit",
+			pages:    [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}},
+			limit:    3,
+			want:     "a b|c",
+			requests: "0 1",
+			count:    3,
+		},
+		{
+			name:     "limit at end of page",
+			pages:    [][]string{{"a", "b"}, {"c", "d"}},
+			limit:    2,
+			want:     "a b",
+			requests: "0",
+			count:    2,
+		},
+		{
+			name:     "stop on nothing new",
+			pages:    [][]string{{"a", "b"}, {"a", "b"}, {"c"}},
+			want:     "a b",
+			requests: "0 1",
+			count:    2,
+		},
+		{
+			name:     "no more results with results",
+			pages:    [][]string{{"a"}, {"b"}},
+			last:     true,
+			want:     "a|b",
+			requests: "0 1",
+			count:    2,
+		},
+		{
+			name:     "no more results without results",
+			pages:    [][]string{{"a"}},
+			want:     "a",
+			requests: "0 1",
+			count:    1,
+		},
+		{
+			name:     "resume",
+			pages:    [][]string{{"a", "b"}, {"b", "c", "d"}, {"e", "f"}},
+			start:    1,
+			limit:    4,
+			resume:   []string{"a", "b", "a"},
+			want:     "c d",
+			requests: "1",
+			count:    4,
+		},
+		{
+			name:     "resume reaches limit",
+			pages:    [][]string{{"a", "b"}},
+			limit:    2,
+			resume:   []string{"a", "b"},
+			want:     "",
+			requests: "",
+			count:    2,
+		},
+	} {
+		engine := &fakeEngine{pages: tt.pages, last: tt.last}
+
+		p := NewPaginator(engine, &SearchRequest{Query: "x", Page: tt.start}, tt.limit)
+		p.Resume(tt.resume)
+
+		if got := paginate(t, p); got != tt.want {
+			t.Errorf("%s: results = %q, want %q", tt.name, got, tt.want)
+		}
+
+		requests := make([]string, len(engine.requests))
+		for i, page := range engine.requests {
+			requests[i] = strconv.Itoa(page)
+		}
+
+		if got := strings.Join(requests, " "); got != tt.requests {
+			t.Errorf("%s: requested pages %q, want %q", tt.name, got, tt.requests)
+		}
+
+		if p.Count() != tt.count || !p.Done() {
+			t.Errorf("%s: Count() = %d, Done() = %v, want %d, true", tt.name, p.Count(), p.Done(), tt.count)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	engine := &fakeEngine{pages: [][]string{{"a", "b"}, {"c"}}, last: true}
+
+	results, err := Paginate(context.Background(), engine, &SearchRequest{Query: "x"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 3 || results[2].Url() != "c" {
+		t.Errorf("Paginate() = %v, want [a b c]", results)
+	}
+}
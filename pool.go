@@ -0,0 +1,406 @@
+package dorkali
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoProxy is returned when pool has no proxy
+var ErrNoProxy = errors.New("dorkali: no available proxy")
+
+// Rotation of proxy pool
+type Rotation string
+
+const (
+	// RotateRequest uses next proxy for every request
+	RotateRequest Rotation = "request"
+
+	// RotateSession uses a proxy for all requests, until it's burnt
+	RotateSession Rotation = "session"
+)
+
+const (
+	// DefaultCooldown is the default duration which a burnt proxy is not used
+	DefaultCooldown = time.Minute * 10
+
+	// DefaultCheckURL is requested to check health of a proxy after its cooldown
+	DefaultCheckURL = "https://www.gstatic.com/generate_204"
+)
+
+// ProxyPool rotates requests of a session between proxies.
+//
+// a proxy is burnt when search engine blocks it (or it fails), and isn't used
+// until its cooldown is elapsed and its health check passes.
+type ProxyPool struct {
+	// Rotation of proxies; default is RotateRequest
+	Rotation Rotation
+
+	// Cooldown of burnt proxies; default is DefaultCooldown
+	Cooldown time.Duration
+
+	// CheckURL is requested through a burnt proxy after its cooldown; the proxy is
+	// used again if response status is less than 400. default is DefaultCheckURL
+	CheckURL string
+
+	mu      sync.Mutex
+	proxies []*poolProxy
+	next    int
+	current *poolProxy
+
+	// running health checks, and channel which is closed when one of them is done
+	checks  int
+	checked chan struct{}
+}
+
+// ProxyStats is statistics of a proxy of pool
+type ProxyStats struct {
+	// Proxy url, without password
+	Proxy string
+
+	// Requests sent through proxy
+	Requests int
+
+	// Errors of requests (network errors)
+	Errors int
+
+	// Blocks by search engines
+	Blocks int
+
+	// Latency is the average time of successful requests
+	Latency time.Duration
+
+	// Burnt is true if proxy is in cooldown, until BurntUntil
+	Burnt      bool
+	BurntUntil time.Time
+}
+
+type poolProxy struct {
+	url   *url.URL
+	stats ProxyStats
+
+	// total time of successful requests
+	latency  time.Duration
+	checking bool
+}
+
+// NewProxyPool returns pool of proxies; see ParseProxy(...)
+func NewProxyPool(proxies ...*url.URL) *ProxyPool {
+	p := &ProxyPool{}
+
+	for _, u := range proxies {
+		p.proxies = append(p.proxies, &poolProxy{url: u, stats: ProxyStats{Proxy: u.Redacted()}})
+	}
+
+	return p
+}
+
+// LoadProxyPool reads proxies of filename, one per line; empty lines and
+// lines starting with '#' are ignored
+func LoadProxyPool(filename string) (*ProxyPool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var proxies []*url.URL
+
+	scanner := bufio.NewScanner(f)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		u, err := ParseProxy(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, n, err)
+		}
+
+		proxies = append(proxies, u)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("dorkali: no proxy in %q", filename)
+	}
+
+	return NewProxyPool(proxies...), nil
+}
+
+// Pick returns proxy of next request; returns ErrNoProxy if pool is empty.
+//
+// health checks of burnt proxies run in background; Pick waits for them only if
+// there's no healthy proxy. if all proxies are in cooldown, Pick waits until the
+// earliest cooldown is elapsed (or ctx is done) and checks that proxy
+func (p *ProxyPool) Pick(ctx context.Context) (*url.URL, error) {
+	for {
+		p.mu.Lock()
+
+		p.recheck()
+		proxy := p.pick()
+		checks, checked := p.checks, p.checked
+		until := p.cooldown()
+
+		p.mu.Unlock()
+
+		if proxy != nil {
+			return proxy.url, nil
+		}
+
+		if checks != 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-checked:
+			}
+			continue
+		}
+
+		if until.IsZero() {
+			return nil, ErrNoProxy
+		}
+
+		timer := time.NewTimer(time.Until(until))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// pick returns next proxy which is not burnt, or nil; p.mu must be held
+func (p *ProxyPool) pick() *poolProxy {
+	if p.Rotation == RotateSession && p.current != nil && !p.current.stats.Burnt {
+		return p.current
+	}
+
+	for i := 0; i < len(p.proxies); i++ {
+		proxy := p.proxies[(p.next+i)%len(p.proxies)]
+		if proxy.stats.Burnt {
+			continue
+		}
+
+		p.next = (p.next + i + 1) % len(p.proxies)
+		p.current = proxy
+		return proxy
+	}
+
+	return nil
+}
+
+// rotate makes next Pick(...) return next proxy, in session rotation too
+func (p *ProxyPool) rotate() {
+	p.mu.Lock()
+	p.current = nil
+	p.mu.Unlock()
+}
+
+// Burn marks proxy as blocked by search engine, and puts it on cooldown
+func (p *ProxyPool) Burn(proxy *url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e := p.find(proxy); e != nil {
+		e.stats.Blocks++
+		p.burn(e)
+	}
+}
+
+// Stats returns statistics of proxies
+func (p *ProxyPool) Stats() []ProxyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]ProxyStats, 0, len(p.proxies))
+	for _, e := range p.proxies {
+		stats = append(stats, e.stats)
+	}
+
+	return stats
+}
+
+// record records a request through proxy; a failed request burns the proxy
+func (p *ProxyPool) record(proxy *url.URL, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e := p.find(proxy)
+	if e == nil {
+		return
+	}
+
+	e.stats.Requests++
+
+	if err != nil {
+		e.stats.Errors++
+		p.burn(e)
+		return
+	}
+
+	e.latency += latency
+	e.stats.Latency = e.latency / time.Duration(e.stats.Requests-e.stats.Errors)
+}
+
+// recheck starts health checks of burnt proxies which their cooldown is elapsed,
+// in background; p.mu must be held
+func (p *ProxyPool) recheck() {
+	now := time.Now()
+
+	checkURL := p.CheckURL
+	if checkURL == "" {
+		checkURL = DefaultCheckURL
+	}
+
+	for _, e := range p.proxies {
+		if !e.stats.Burnt || e.checking || now.Before(e.stats.BurntUntil) {
+			continue
+		}
+
+		e.checking = true
+		p.checks++
+
+		if p.checked == nil {
+			p.checked = make(chan struct{})
+		}
+
+		go p.check(e, checkURL)
+	}
+}
+
+// cooldown returns the earliest end of cooldown of burnt proxies which are not
+// checking, or zero time if there's no one; p.mu must be held
+func (p *ProxyPool) cooldown() time.Time {
+	var until time.Time
+
+	for _, e := range p.proxies {
+		if !e.stats.Burnt || e.checking {
+			continue
+		}
+
+		if until.IsZero() || e.stats.BurntUntil.Before(until) {
+			until = e.stats.BurntUntil
+		}
+	}
+
+	return until
+}
+
+// check checks health of burnt proxy e; the proxy is used again if it passes,
+// or its cooldown starts again
+func (p *ProxyPool) check(e *poolProxy, checkURL string) {
+	err := checkProxy(context.Background(), e.url, checkURL)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e.checking = false
+
+	if err == nil {
+		e.stats.Burnt = false
+		e.stats.BurntUntil = time.Time{}
+	} else {
+		p.burn(e)
+	}
+
+	p.checks--
+
+	// wake up waiting Pick(...) calls
+	close(p.checked)
+	p.checked = make(chan struct{})
+}
+
+func (p *ProxyPool) burn(e *poolProxy) {
+	cooldown := p.Cooldown
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+
+	e.stats.Burnt = true
+	e.stats.BurntUntil = time.Now().Add(cooldown)
+}
+
+func (p *ProxyPool) find(proxy *url.URL) *poolProxy {
+	if proxy == nil {
+		return nil
+	}
+
+	for _, e := range p.proxies {
+		if e.url == proxy || e.url.String() == proxy.String() {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// checkProxy requests checkURL through proxy
+func checkProxy(ctx context.Context, proxy *url.URL, checkURL string) error {
+	transport := &http.Transport{Proxy: http.ProxyURL(proxy)}
+	defer transport.CloseIdleConnections()
+
+	cli := http.Client{Transport: transport, Timeout: time.Second * 10}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", checkURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("dorkali: proxy check returns status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// proxyKey is context key of proxy which is picked for a request
+type proxyKey struct{}
+
+// usedProxyKey is context key of *usedProxy, which remembers proxy of last
+// request of a search
+type usedProxyKey struct{}
+
+type usedProxy struct {
+	mu    sync.Mutex
+	proxy *url.URL
+}
+
+func (u *usedProxy) set(proxy *url.URL) {
+	u.mu.Lock()
+	u.proxy = proxy
+	u.mu.Unlock()
+}
+
+func (u *usedProxy) get() *url.URL {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.proxy
+}
+
+// proxyOf returns proxy which picked from pool for a request of ctx, or nil
+func proxyOf(ctx context.Context) *url.URL {
+	proxy, _ := ctx.Value(proxyKey{}).(*url.URL)
+	return proxy
+}
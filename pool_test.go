@@ -0,0 +1,59 @@
+package dorkali
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestProxyPoolPickWaitsForCooldown(t *testing.T) {
+	// proxy server which passes health checks
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	proxy, _ := url.Parse(srv.URL)
+
+	pool := NewProxyPool(proxy)
+	pool.Cooldown = time.Millisecond * 100
+	pool.CheckURL = "http://check.invalid/generate_204"
+
+	pool.Burn(proxy)
+
+	// ctx is done before cooldown
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	if _, err := pool.Pick(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Pick() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	start := time.Now()
+
+	got, err := pool.Pick(context.Background())
+	if err != nil {
+		t.Fatalf("Pick() error = %v, want proxy after cooldown", err)
+	}
+
+	if got.String() != proxy.String() {
+		t.Errorf("Pick() = %s, want %s", got, proxy)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Millisecond*50 {
+		t.Errorf("Pick() returns after %s, want to wait for cooldown", elapsed)
+	}
+
+	if st := pool.Stats()[0]; st.Burnt {
+		t.Errorf("proxy is burnt after passing health check: %+v", st)
+	}
+}
+
+func TestProxyPoolPickEmpty(t *testing.T) {
+	if _, err := NewProxyPool().Pick(context.Background()); err != ErrNoProxy {
+		t.Errorf("Pick() error = %v, want ErrNoProxy", err)
+	}
+}
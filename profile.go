@@ -0,0 +1,179 @@
+package dorkali
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Profile is a consistent set of headers of a browser; headers of different
+// browsers are never mixed.
+type Profile struct {
+	// Name of profile, e.g. "chrome-windows"
+	Name string
+
+	// Headers which the browser sends on a navigation, by canonical name;
+	// Sec-Fetch-Site is set by Apply(...) from Referer of request.
+	//
+	// order of headers is not kept; net/http writes headers in its own order.
+	Headers map[string]string
+}
+
+// accept encoding of all profiles; only encodings which ReadBody(...) decodes
+const acceptEncoding = "gzip, deflate"
+
+const (
+	chromeAccept  = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"
+	firefoxAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"
+	safariAccept  = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+)
+
+var profiles = []*Profile{
+	chromeProfile("chrome-windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		`"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`, `"Windows"`),
+	chromeProfile("chrome-macos", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		`"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`, `"macOS"`),
+	chromeProfile("chrome-linux", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		`"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`, `"Linux"`),
+	chromeProfile("edge-windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0",
+		`"Chromium";v="124", "Microsoft Edge";v="124", "Not-A.Brand";v="99"`, `"Windows"`),
+	firefoxProfile("firefox-windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0"),
+	firefoxProfile("firefox-macos", "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0"),
+	firefoxProfile("firefox-linux", "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0"),
+	{
+		Name: "safari-macos",
+		Headers: map[string]string{
+			"Accept":          safariAccept,
+			"Sec-Fetch-Site":  "",
+			"Sec-Fetch-Dest":  "document",
+			"Accept-Language": "en-US,en;q=0.9",
+			"Sec-Fetch-Mode":  "navigate",
+			"User-Agent":      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Safari/605.1.15",
+			"Accept-Encoding": acceptEncoding,
+		},
+	},
+}
+
+func chromeProfile(name, ua, brands, platform string) *Profile {
+	return &Profile{
+		Name: name,
+		Headers: map[string]string{
+			"Sec-Ch-Ua":                 brands,
+			"Sec-Ch-Ua-Mobile":          "?0",
+			"Sec-Ch-Ua-Platform":        platform,
+			"Upgrade-Insecure-Requests": "1",
+			"User-Agent":                ua,
+			"Accept":                    chromeAccept,
+			"Sec-Fetch-Site":            "",
+			"Sec-Fetch-Mode":            "navigate",
+			"Sec-Fetch-User":            "?1",
+			"Sec-Fetch-Dest":            "document",
+			"Accept-Encoding":           acceptEncoding,
+			"Accept-Language":           "en-US,en;q=0.9",
+		},
+	}
+}
+
+func firefoxProfile(name, ua string) *Profile {
+	return &Profile{
+		Name: name,
+		Headers: map[string]string{
+			"User-Agent":                ua,
+			"Accept":                    firefoxAccept,
+			"Accept-Language":           "en-US,en;q=0.5",
+			"Accept-Encoding":           acceptEncoding,
+			"Upgrade-Insecure-Requests": "1",
+			"Sec-Fetch-Dest":            "document",
+			"Sec-Fetch-Mode":            "navigate",
+			"Sec-Fetch-Site":            "",
+			"Sec-Fetch-User":            "?1",
+		},
+	}
+}
+
+// Profiles returns names of built-in profiles
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// GetProfile returns built-in profile by name; "random" returns a random profile
+func GetProfile(name string) (*Profile, error) {
+	if name == "random" {
+		return RandomProfile(), nil
+	}
+
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("dorkali: unknown profile %q; use one of %s or random", name, strings.Join(Profiles(), ", "))
+}
+
+// RandomProfile returns a random built-in profile
+func RandomProfile() *Profile {
+	return profiles[rand.Intn(len(profiles))]
+}
+
+// UserAgent returns User-Agent header of profile
+func (p *Profile) UserAgent() string {
+	return p.Headers["User-Agent"]
+}
+
+// Apply sets headers of profile on req, except headers which are already set
+func (p *Profile) Apply(req *http.Request) {
+	for name, value := range p.Headers {
+		if req.Header.Get(name) != "" {
+			continue
+		}
+
+		if name == "Sec-Fetch-Site" {
+			value = fetchSite(req)
+		}
+
+		req.Header.Set(name, value)
+	}
+}
+
+// fetchSite returns value of Sec-Fetch-Site header of req, from its Referer
+func fetchSite(req *http.Request) string {
+	referer := req.Header.Get("Referer")
+	if referer == "" {
+		return "none"
+	}
+
+	u, err := url.Parse(referer)
+	if err != nil {
+		return "cross-site"
+	}
+
+	if u.Scheme == req.URL.Scheme && u.Host == req.URL.Host {
+		return "same-origin"
+	}
+
+	if site(u.Hostname()) == site(req.URL.Hostname()) {
+		return "same-site"
+	}
+
+	return "cross-site"
+}
+
+// site returns last two labels of host, e.g. "google.com" of "www.google.com"
+func site(host string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+
+	return strings.Join(labels[len(labels)-2:], ".")
+}
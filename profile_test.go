@@ -0,0 +1,34 @@
+package dorkali
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestProfileApply(t *testing.T) {
+	p, err := GetProfile("chrome-linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "https://www.google.com/search?q=x&hl=de", nil)
+	req.Header.Set("Accept-Language", "de")
+	req.Header.Set("Referer", "https://www.google.com/")
+
+	p.Apply(req)
+
+	for name, want := range map[string]string{
+		"Accept-Language": "de",
+		"Sec-Fetch-Site":  "same-origin",
+		"User-Agent":      p.UserAgent(),
+		"Accept-Encoding": acceptEncoding,
+	} {
+		if got := req.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	if got := len(req.Header); got != len(p.Headers)+1 {
+		t.Errorf("request has %d headers, want %d", got, len(p.Headers)+1)
+	}
+}
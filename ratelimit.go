@@ -0,0 +1,306 @@
+package dorkali
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned when hourly or daily request budget of an engine is used up
+var ErrBudgetExceeded = errors.New("dorkali: request budget exceeded")
+
+// Rate is N requests per duration
+type Rate struct {
+	N   int
+	Per time.Duration
+}
+
+// ParseRate parses a rate like "10/m": number of requests per s (second),
+// m (minute), h (hour), d (day) or a duration (e.g. "1/2s", "5/30m").
+// empty string is zero rate, which means no limit.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Rate{}, nil
+	}
+
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return Rate{}, fmt.Errorf("dorkali: invalid rate %q; use N/UNIT, e.g. 10/m", s)
+	}
+
+	n, err := strconv.Atoi(parts[0])
+	if err != nil || n <= 0 {
+		return Rate{}, fmt.Errorf("dorkali: invalid rate %q; number of requests must be positive", s)
+	}
+
+	var per time.Duration
+
+	switch parts[1] {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	case "d":
+		per = time.Hour * 24
+	default:
+		per, err = time.ParseDuration(parts[1])
+		if err != nil || per <= 0 {
+			return Rate{}, fmt.Errorf("dorkali: invalid rate %q; unit is s, m, h, d or a duration", s)
+		}
+	}
+
+	return Rate{N: n, Per: per}, nil
+}
+
+// IsZero returns true if rate has no limit
+func (r Rate) IsZero() bool {
+	return r.N <= 0 || r.Per <= 0
+}
+
+func (r Rate) String() string {
+	if r.IsZero() {
+		return "unlimited"
+	}
+	return strconv.Itoa(r.N) + "/" + r.Per.String()
+}
+
+// RateLimiter throttles requests of sessions, with a token bucket per engine
+// and per proxy, a random jitter, and hourly and daily budgets per engine.
+//
+// requests of an engine are requests which are sent by API methods; other
+// requests are grouped by their host.
+type RateLimiter struct {
+	// Rate of requests per engine; zero means no limit
+	Rate Rate
+
+	// ProxyRate is rate of requests per proxy; zero means no limit
+	ProxyRate Rate
+
+	// Burst is number of requests which can be sent at once; default is 1
+	Burst int
+
+	// Jitter is maximum of random delay which is added before each request
+	Jitter time.Duration
+
+	// Hourly and Daily are request budgets per engine; zero means no limit
+	Hourly int
+	Daily  int
+
+	// StateFile (if not empty) keeps used budgets between runs; it's loaded by
+	// first request, and saved after each request
+	StateFile string
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	budgets map[string]*budget
+	loaded  bool
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// budget is number of requests of an engine in current hour and day
+type budget struct {
+	Hour      string `json:"hour"`
+	HourCount int    `json:"hour_count"`
+	Day       string `json:"day"`
+	DayCount  int    `json:"day_count"`
+}
+
+// NewRateLimiter returns rate limiter of rate per engine
+func NewRateLimiter(rate Rate) *RateLimiter {
+	return &RateLimiter{Rate: rate}
+}
+
+// Wait waits for turn of a request of engine through proxy (empty if no proxy).
+//
+// returns ErrBudgetExceeded if budget of engine is used up, and ctx.Err() if
+// ctx is done before turn
+func (l *RateLimiter) Wait(ctx context.Context, engine, proxy string) error {
+	l.mu.Lock()
+
+	if err := l.load(); err != nil {
+		l.mu.Unlock()
+		return err
+	}
+
+	now := time.Now()
+
+	if err := l.spend(engine, now); err != nil {
+		l.mu.Unlock()
+		return err
+	}
+
+	delay := l.reserve("engine\x00"+engine, l.Rate, now)
+
+	if proxy != "" {
+		if d := l.reserve("proxy\x00"+proxy, l.ProxyRate, now); d > delay {
+			delay = d
+		}
+	}
+
+	if l.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(l.Jitter)))
+	}
+
+	err := l.save()
+	l.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	return sleep(ctx, delay)
+}
+
+// reserve takes a token of bucket of key, and returns delay until the token is available
+func (l *RateLimiter) reserve(key string, rate Rate, now time.Time) time.Duration {
+	if rate.IsZero() {
+		return 0
+	}
+
+	burst := float64(l.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+	}
+
+	// tokens per second
+	perSecond := float64(rate.N) / rate.Per.Seconds()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * perSecond
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+		b.last = now
+	}
+
+	// token is taken now; negative tokens are reserved by waiting requests
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / perSecond * float64(time.Second))
+}
+
+// spend counts a request of engine in its budgets, or returns ErrBudgetExceeded
+func (l *RateLimiter) spend(engine string, now time.Time) error {
+	if l.Hourly <= 0 && l.Daily <= 0 {
+		return nil
+	}
+
+	if l.budgets == nil {
+		l.budgets = make(map[string]*budget)
+	}
+
+	b, ok := l.budgets[engine]
+	if !ok {
+		b = &budget{}
+		l.budgets[engine] = b
+	}
+
+	now = now.UTC()
+	hour, day := now.Format("2006-01-02T15"), now.Format("2006-01-02")
+
+	if b.Hour != hour {
+		b.Hour, b.HourCount = hour, 0
+	}
+
+	if b.Day != day {
+		b.Day, b.DayCount = day, 0
+	}
+
+	if l.Hourly > 0 && b.HourCount >= l.Hourly {
+		return fmt.Errorf("%w ( %s: %d requests per hour )", ErrBudgetExceeded, engine, l.Hourly)
+	}
+
+	if l.Daily > 0 && b.DayCount >= l.Daily {
+		return fmt.Errorf("%w ( %s: %d requests per day )", ErrBudgetExceeded, engine, l.Daily)
+	}
+
+	b.HourCount++
+	b.DayCount++
+
+	return nil
+}
+
+// load reads budgets of StateFile once
+func (l *RateLimiter) load() error {
+	if l.loaded || l.StateFile == "" {
+		return nil
+	}
+
+	l.loaded = true
+
+	b, err := os.ReadFile(l.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	budgets := make(map[string]*budget)
+	if err := json.Unmarshal(b, &budgets); err != nil {
+		return fmt.Errorf("dorkali: invalid rate limit state %q: %w", l.StateFile, err)
+	}
+
+	l.budgets = budgets
+	return nil
+}
+
+// save writes budgets to StateFile atomically
+func (l *RateLimiter) save() error {
+	if l.StateFile == "" || l.budgets == nil {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(l.budgets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.StateFile), 0o700); err != nil {
+		return err
+	}
+
+	return writeFile(l.StateFile, b)
+}
+
+// engineKey is context key of engine name of requests
+type engineKey struct{}
+
+// engineOf returns engine name of a request of ctx, or empty string
+func engineOf(ctx context.Context) string {
+	name, _ := ctx.Value(engineKey{}).(string)
+	return name
+}
@@ -0,0 +1,167 @@
+package dorkali
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultCount is number of results per page when SearchRequest.Count is not set
+const DefaultCount = 10
+
+// TimeRange limits results to a recent period of time
+type TimeRange string
+
+const (
+	AnyTime   TimeRange = ""
+	PastHour  TimeRange = "h"
+	PastDay   TimeRange = "d"
+	PastWeek  TimeRange = "w"
+	PastMonth TimeRange = "m"
+	PastYear  TimeRange = "y"
+)
+
+// ParseTimeRange parses time range names
+//
+// accepts "", "any", "h", "hour", "d", "day", "w", "week", "m", "month", "y", "year"
+func ParseTimeRange(s string) (TimeRange, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "any":
+		return AnyTime, nil
+	case "h", "hour":
+		return PastHour, nil
+	case "d", "day":
+		return PastDay, nil
+	case "w", "week":
+		return PastWeek, nil
+	case "m", "month":
+		return PastMonth, nil
+	case "y", "year":
+		return PastYear, nil
+	}
+
+	return AnyTime, fmt.Errorf("dorkali: invalid time range %q", s)
+}
+
+// SearchRequest describes a search, independent of engines.
+//
+// Every engine maps these fields to its own parameters, and ignores
+// fields which it doesn't support.
+type SearchRequest struct {
+	// Query to search
+	Query string
+
+	// Page of results, starts from zero
+	Page int
+
+	// Number of results per page (default DefaultCount)
+	Count int
+
+	// Language of results (e.g. en, de, fr, ...)
+	Language string
+
+	// Country or region to focus the search on (e.g. us, de, fr, ...)
+	Region string
+
+	// Safe search
+	SafeSearch bool
+
+	// Limits results to a recent period of time
+	TimeRange TimeRange
+
+	// (Query helper) ... site:"TEXT" ...
+	Site string
+
+	// (Query helper) ... inurl:"TEXT" ...
+	Inurl string
+
+	// (Query helper) ... intitle:"TEXT" ...
+	Intitle string
+
+	// (Query helper) ... intext:"TEXT" ...
+	Intext string
+
+	// (Query helper) ... filetype:"TEXT" ...
+	Filetype string
+
+	// (Query helper) ... ext:"TEXT" ...
+	Ext string
+
+	// Additional request headers
+	Header http.Header
+
+	// Additional request cookies
+	Cookies []*http.Cookie
+
+	// Maximum time allowed for request; zero means no timeout
+	Timeout time.Duration
+}
+
+// Validate returns error if request is not usable
+func (r *SearchRequest) Validate() error {
+	if r == nil {
+		return errors.New("dorkali: nil search request")
+	}
+
+	if strings.TrimSpace(r.Query) == "" && r.Site == "" && r.Inurl == "" &&
+		r.Intitle == "" && r.Intext == "" && r.Filetype == "" && r.Ext == "" {
+		return errors.New("dorkali: query is required")
+	}
+
+	if r.Page < 0 {
+		return fmt.Errorf("dorkali: invalid page %d", r.Page)
+	}
+
+	if r.Count < 0 {
+		return fmt.Errorf("dorkali: invalid count %d", r.Count)
+	}
+
+	return nil
+}
+
+// Clone returns a deep copy of r
+func (r *SearchRequest) Clone() *SearchRequest {
+	c := *r
+
+	c.Header = r.Header.Clone()
+
+	if r.Cookies != nil {
+		c.Cookies = make([]*http.Cookie, len(r.Cookies))
+		for i, cookie := range r.Cookies {
+			cp := *cookie
+			c.Cookies[i] = &cp
+		}
+	}
+
+	return &c
+}
+
+// PerPage returns r.Count, or DefaultCount if not set
+func (r *SearchRequest) PerPage() int {
+	if r.Count <= 0 {
+		return DefaultCount
+	}
+	return r.Count
+}
+
+// Offset returns index of first result of r.Page
+func (r *SearchRequest) Offset() int {
+	return r.Page * r.PerPage()
+}
+
+// Operator returns `name:value`, and quotes value if has spaces
+//
+// returns empty string if value is empty
+func Operator(name, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	if strings.ContainsAny(value, " \t") && !strings.HasPrefix(value, `"`) {
+		value = `"` + value + `"`
+	}
+
+	return name + ":" + value
+}
@@ -0,0 +1,90 @@
+package dorkali
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ReadBody reads and closes response body, and decodes it if gzip or deflate encoded.
+//
+// Reading stops when ctx is done, and returns ctx.Err()
+func ReadBody(ctx context.Context, response *http.Response) ([]byte, error) {
+	defer response.Body.Close()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// close body when ctx is done, to stop blocked reads
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			response.Body.Close()
+		case <-done:
+		}
+	}()
+
+	var r io.Reader = response.Body
+
+	switch strings.ToLower(response.Header.Get("Content-Encoding")) {
+	case "gzip":
+		decoder, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		defer decoder.Close()
+
+		r = decoder
+
+	case "deflate":
+		decoder, err := deflateReader(r)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		defer decoder.Close()
+
+		r = decoder
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+
+	return b, nil
+}
+
+// ctxErr returns ctx.Err() if ctx is done, otherwise err
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// deflateReader returns decoder of deflate encoded r; servers send zlib
+// wrapped (as RFC) or raw deflate data, so both are accepted
+func deflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// zlib header: compression method 8, and header is multiple of 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+
+	return flate.NewReader(br), nil
+}
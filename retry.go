@@ -0,0 +1,208 @@
+package dorkali
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultBackoff is the default delay before second attempt of a request
+	DefaultBackoff = time.Second
+
+	// DefaultMaxBackoff is the default maximum delay between attempts
+	DefaultMaxBackoff = time.Minute
+)
+
+// RetryPolicy retries requests of a session which fail by network errors, or
+// are responded by status 429 or 5xx; delays between attempts grow exponentially
+// with a random jitter, and Retry-After header is respected.
+type RetryPolicy struct {
+	// Attempts is maximum number of attempts of a request; less than 2 means no retry
+	Attempts int
+
+	// Backoff is delay before second attempt, which is doubled for next attempts;
+	// default is DefaultBackoff
+	Backoff time.Duration
+
+	// MaxBackoff is maximum delay between attempts; a response which its Retry-After
+	// is longer than MaxBackoff is not retried. default is DefaultMaxBackoff
+	MaxBackoff time.Duration
+
+	// RotateProxy uses next proxy of pool for next attempt
+	RotateProxy bool
+
+	// RotateProfile uses another random profile for next attempt (and next requests)
+	RotateProfile bool
+}
+
+// SetRetryPolicy sets retry policy of session; nil policy disables retries
+func (s *Session) SetRetryPolicy(policy *RetryPolicy) {
+	s.mu.Lock()
+	s.retry = policy
+	s.mu.Unlock()
+}
+
+// RetryPolicy returns retry policy of session, or nil if not set
+func (s *Session) RetryPolicy() *RetryPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.retry
+}
+
+// Do sends req with session client, cookies and headers of session profile
+// (headers of req are kept), and retries it by retry policy of session; req is
+// not changed, so it can be sent again.
+//
+// timeout (if not zero) limits time of each attempt, including reading response body.
+func (s *Session) Do(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	policy := s.RetryPolicy()
+
+	attempts := 1
+	if policy != nil && policy.Attempts > 1 {
+		attempts = policy.Attempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := s.send(req, timeout)
+
+		if attempt >= attempts || !retryable(req, resp, err) {
+			return resp, err
+		}
+
+		delay, ok := policy.delay(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = "status code " + strconv.Itoa(resp.StatusCode)
+
+			// read rest of body to reuse connection
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		s.Logf("|  attempt %d/%d failed ( %s ); retrying in %s\n\n", attempt, attempts, reason, delay.Round(time.Millisecond))
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if policy.RotateProxy {
+			if pool := s.ProxyPool(); pool != nil {
+				pool.rotate()
+			}
+		}
+
+		if policy.RotateProfile {
+			s.SetProfile(otherProfile(s.Profile()))
+		}
+	}
+}
+
+// challengeKey is context key of function which detects challenge pages of requests
+type challengeKey struct{}
+
+// WithChallenge returns a copy of ctx, which requests bound to it are not retried when
+// isChallenge returns true for their response; engines use it for their captcha pages
+// (e.g. a redirect to /sorry/ of google with status 429), which are not passed by
+// sending request again.
+func WithChallenge(ctx context.Context, isChallenge func(resp *http.Response) bool) context.Context {
+	return context.WithValue(ctx, challengeKey{}, isChallenge)
+}
+
+// retryable returns true if req may be sent again after its response or error
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	// body can't be sent again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		return !errors.Is(err, ErrNoProxy) && !errors.Is(err, ErrBudgetExceeded)
+	}
+
+	if isChallenge, ok := req.Context().Value(challengeKey{}).(func(*http.Response) bool); ok && isChallenge(resp) {
+		return false
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// delay returns delay before next attempt of attempt; returns false if
+// Retry-After of resp is longer than MaxBackoff
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = DefaultMaxBackoff
+	}
+
+	d := backoff
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+
+	if d > max {
+		d = max
+	}
+
+	// random jitter in [d/2, d]
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+
+	if resp != nil {
+		retryAfter := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if retryAfter > max {
+			return 0, false
+		}
+
+		if retryAfter > d {
+			d = retryAfter
+		}
+	}
+
+	return d, true
+}
+
+// otherProfile returns a random built-in profile other than p
+func otherProfile(p *Profile) *Profile {
+	if len(profiles) < 2 {
+		return p
+	}
+
+	for {
+		if other := RandomProfile(); other != p {
+			return other
+		}
+	}
+}
+
+// sleep waits for d, or returns ctx.Err() if ctx is done before
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
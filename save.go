@@ -0,0 +1,191 @@
+package dorkali
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxPageSize is maximum size of body which is read to pass a page to hook,
+// when body is closed before it's read completely
+const maxPageSize = 8 << 20
+
+// Page is a page which a session fetched, with its request and response
+type Page struct {
+	// URL and Method of request; URL is the last url, if request is redirected
+	URL    string `json:"url"`
+	Method string `json:"method"`
+
+	// Engine which sent the request, or host of url for other requests
+	Engine string `json:"engine"`
+
+	// Proxy of request, without password
+	Proxy string `json:"proxy,omitempty"`
+
+	RequestHeader http.Header `json:"request_header"`
+
+	StatusCode     int         `json:"status_code"`
+	ResponseHeader http.Header `json:"response_header"`
+
+	// Time which response is received
+	Time time.Time `json:"time"`
+
+	// Body is decoded html of response; it's passed to ParseHTML(...) to reproduce a parse
+	Body []byte `json:"-"`
+}
+
+// PageHook is called with every page which a session fetches, when its body
+// is read or closed by engine
+type PageHook func(page *Page) error
+
+// SetPageHook sets hook of pages of session; nil hook removes it.
+// see SavePages(...)
+func (s *Session) SetPageHook(hook PageHook) {
+	s.mu.Lock()
+	s.hook = hook
+	s.mu.Unlock()
+}
+
+// PageHook returns page hook of session, or nil if not set
+func (s *Session) PageHook() PageHook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.hook
+}
+
+// SavePages returns a page hook which writes pages to dir; each page is a file pair
+// named by its time and engine: TIME-ENGINE-N.html has the body, and
+// TIME-ENGINE-N.json has url, headers and status of request and response.
+func SavePages(dir string) PageHook {
+	var n int64
+
+	return func(page *Page) error {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+
+		meta, err := json.MarshalIndent(page, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%s-%s-%d", page.Time.Format("20060102T150405.000"), page.Engine, atomic.AddInt64(&n, 1))
+		name = filepath.Join(dir, strings.NewReplacer("/", "_", ":", "_").Replace(name))
+
+		if err := writeFile(name+".html", page.Body); err != nil {
+			return err
+		}
+
+		return writeFile(name+".json", meta)
+	}
+}
+
+// hookBody passes body of a response to hook of session, when it's read or closed
+type hookBody struct {
+	io.ReadCloser
+
+	ctx     context.Context
+	page    *Page
+	session *Session
+	hook    PageHook
+
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	once sync.Once
+}
+
+// newHookBody returns body of resp which calls hook with page of resp
+func newHookBody(s *Session, hook PageHook, resp *http.Response, engine, proxy string) *hookBody {
+	page := &Page{
+		URL:            resp.Request.URL.String(),
+		Method:         resp.Request.Method,
+		Engine:         engine,
+		Proxy:          proxy,
+		RequestHeader:  resp.Request.Header.Clone(),
+		StatusCode:     resp.StatusCode,
+		ResponseHeader: resp.Header.Clone(),
+		Time:           time.Now().UTC(),
+	}
+
+	return &hookBody{ReadCloser: resp.Body, ctx: resp.Request.Context(), page: page, session: s, hook: hook}
+}
+
+func (b *hookBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	b.buf.Write(p[:n])
+	b.mu.Unlock()
+
+	if err == io.EOF {
+		b.done()
+	}
+
+	return n, err
+}
+
+// Close reads rest of body (e.g. a captcha page which is not parsed) before closing,
+// unless request is canceled
+func (b *hookBody) Close() error {
+	if b.ctx.Err() == nil {
+		io.Copy(io.Discard, io.LimitReader(b, maxPageSize))
+	}
+
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+// done calls hook once
+func (b *hookBody) done() {
+	b.once.Do(func() {
+		b.mu.Lock()
+		raw := append([]byte(nil), b.buf.Bytes()...)
+		b.mu.Unlock()
+
+		body, err := decodeBody(raw, b.page.ResponseHeader.Get("Content-Encoding"))
+		if err != nil {
+			// keep encoded body
+			body = raw
+		}
+
+		b.page.Body = body
+
+		if err := b.hook(b.page); err != nil {
+			b.session.Logf("|  page hook: %s\n\n", err.Error())
+		}
+	})
+}
+
+// decodeBody decodes b if it's gzip or deflate encoded
+func decodeBody(b []byte, encoding string) ([]byte, error) {
+	var decoder io.ReadCloser
+	var err error
+
+	switch strings.ToLower(encoding) {
+	case "gzip":
+		decoder, err = gzip.NewReader(bytes.NewReader(b))
+	case "deflate":
+		decoder, err = deflateReader(bytes.NewReader(b))
+	default:
+		return b, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	return io.ReadAll(decoder)
+}
@@ -0,0 +1,257 @@
+package dorkali
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Session is shared between requests of engines; it keeps cookies which
+// search engines set in its Jar, and sends requests with a shared client,
+// so connections and cookies are reused.
+type Session struct {
+	// Jar keeps cookies of session; save it with Jar.Save(...) to reuse them in next runs
+	Jar *Jar
+
+	// Verbose prints requests and responses to stderr
+	Verbose bool
+
+	client    *http.Client
+	transport *http.Transport
+
+	mu       sync.Mutex
+	proxyURL *url.URL
+	pool     *ProxyPool
+	profile  *Profile
+	limiter  *RateLimiter
+	retry    *RetryPolicy
+	hook     PageHook
+}
+
+// NewSession returns a session with an empty jar and a random profile
+func NewSession() *Session {
+	s := &Session{Jar: NewJar(), profile: RandomProfile()}
+
+	s.transport = http.DefaultTransport.(*http.Transport).Clone()
+	s.transport.Proxy = s.proxy
+
+	s.client = &http.Client{Jar: s.Jar, Transport: s.transport}
+
+	return s
+}
+
+// Transport returns transport of session, to configure TLS, connections and timeouts;
+// don't change its Proxy, use SetProxy(...) instead
+func (s *Session) Transport() *http.Transport {
+	return s.transport
+}
+
+// SetProxy routes requests of session through proxy (see ParseProxy); nil
+// proxy uses proxy of environment (HTTP_PROXY, HTTPS_PROXY and NO_PROXY)
+func (s *Session) SetProxy(proxy *url.URL) {
+	s.mu.Lock()
+	s.proxyURL = proxy
+	s.mu.Unlock()
+
+	// don't reuse connections of previous proxy
+	s.transport.CloseIdleConnections()
+}
+
+// Proxy returns proxy of session, or nil if not set
+func (s *Session) Proxy() *url.URL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.proxyURL
+}
+
+// SetProfile sets browser profile of session, which its headers are sent
+// with requests; nil profile sends no header of browsers
+func (s *Session) SetProfile(profile *Profile) {
+	s.mu.Lock()
+	s.profile = profile
+	s.mu.Unlock()
+}
+
+// Profile returns browser profile of session
+func (s *Session) Profile() *Profile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.profile
+}
+
+// SetRateLimiter throttles requests of session by limiter; nil limiter removes it
+func (s *Session) SetRateLimiter(limiter *RateLimiter) {
+	s.mu.Lock()
+	s.limiter = limiter
+	s.mu.Unlock()
+}
+
+// RateLimiter returns rate limiter of session, or nil if not set
+func (s *Session) RateLimiter() *RateLimiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.limiter
+}
+
+// SetProxyPool rotates requests of session between proxies of pool; it
+// overrides proxy of SetProxy(...). nil pool removes it.
+func (s *Session) SetProxyPool(pool *ProxyPool) {
+	s.mu.Lock()
+	s.pool = pool
+	s.mu.Unlock()
+}
+
+// ProxyPool returns proxy pool of session, or nil if not set
+func (s *Session) ProxyPool() *ProxyPool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pool
+}
+
+// send sends req once, through proxy and rate limiter of session; see Do(...)
+func (s *Session) send(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	// request of caller is never changed (client adds cookies of jar to its header),
+	// so it can be sent again
+	req = req.Clone(req.Context())
+
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+
+	if profile := s.Profile(); profile != nil {
+		profile.Apply(req)
+	}
+
+	parent := req.Context()
+	pool := s.ProxyPool()
+	proxy := s.Proxy()
+
+	if pool != nil {
+		var err error
+		if proxy, err = pool.Pick(parent); err != nil {
+			return nil, err
+		}
+
+		req = req.WithContext(context.WithValue(parent, proxyKey{}, proxy))
+
+		if used, ok := parent.Value(usedProxyKey{}).(*usedProxy); ok {
+			used.set(proxy)
+		}
+	}
+
+	engine := engineOf(parent)
+	if engine == "" {
+		engine = req.URL.Hostname()
+	}
+
+	if limiter := s.RateLimiter(); limiter != nil {
+		name := ""
+		if proxy != nil {
+			name = proxy.String()
+		}
+
+		if err := limiter.Wait(parent, engine, name); err != nil {
+			return nil, err
+		}
+	}
+
+	var cancel context.CancelFunc
+
+	if timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+		req = req.WithContext(ctx)
+	}
+
+	if s.Verbose {
+		if profile := s.Profile(); profile != nil {
+			s.Logf("|  profile: %s\n", profile.Name)
+		}
+
+		if proxy != nil {
+			s.Logf("|  proxy: %s\n", proxy.Redacted())
+		}
+
+		s.Logf("|  %s %s\n\n", req.Method, req.URL.String())
+
+		for k, values := range req.Header {
+			for _, v := range values {
+				s.Logf("|> %s: %s\n", k, v)
+			}
+		}
+	}
+
+	start := time.Now()
+
+	resp, err := s.client.Do(req)
+
+	if pool != nil && parent.Err() == nil {
+		pool.record(proxy, time.Since(start), err)
+	}
+
+	if err != nil {
+		if cancel != nil {
+			cancel()
+		}
+		return nil, err
+	}
+
+	if cancel != nil {
+		resp.Body = &cancelBody{resp.Body, cancel}
+	}
+
+	if hook := s.PageHook(); hook != nil {
+		name := ""
+		if proxy != nil {
+			name = proxy.Redacted()
+		}
+
+		resp.Body = newHookBody(s, hook, resp, engine, name)
+	}
+
+	if s.Verbose {
+		s.Logf("\n")
+
+		for k, values := range resp.Header {
+			for _, v := range values {
+				s.Logf("|< %s: %s\n", k, v)
+			}
+		}
+
+		s.Logf("\n")
+	}
+
+	return resp, nil
+}
+
+// Logf prints a message to stderr if session is verbose
+func (s *Session) Logf(format string, args ...interface{}) {
+	if s.Verbose {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
+
+// cancelBody cancels context of request when body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,59 @@
+package dorkali
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSessionDoRetryKeepsRequest(t *testing.T) {
+	var cookies, bodies []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		bodies = append(bodies, string(b))
+
+		http.SetCookie(w, &http.Cookie{Name: "c", Value: "v"})
+
+		if len(cookies) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewSession()
+	s.SetProfile(nil)
+	s.SetRetryPolicy(&RetryPolicy{Attempts: 3, Backoff: 1})
+
+	req, err := http.NewRequest("POST", srv.URL, strings.NewReader("q=x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.Do(req, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+
+	want := []string{"", "c=v", "c=v"}
+	if strings.Join(cookies, "|") != strings.Join(want, "|") {
+		t.Errorf("Cookie headers = %q, want %q", cookies, want)
+	}
+
+	if strings.Join(bodies, "|") != "q=x|q=x|q=x" {
+		t.Errorf("bodies = %q, want q=x on every attempt", bodies)
+	}
+
+	if len(req.Header) != 0 {
+		t.Errorf("request of caller is changed: %v", req.Header)
+	}
+}